@@ -66,13 +66,36 @@ resource "random_password" "password" {
     override_special = "_-#"
 }
 
-data "template_file" "cloud_init" {
-    template = file("cloud-config.tpl")
-    vars = {
-        user_login        = var.user_login
-        public_key        = file(var.public_key)
-        hostname          = "gitlab"
-        gitlab_password   = random_password.password.result
+data "rustack_cloudinit_config" "cloud_init" {
+    part {
+        content = yamlencode({
+            debug = { verbose = true }
+            users = [{
+                name                = var.user_login
+                sudo                = ["ALL=(ALL) NOPASSWD:ALL"]
+                groups              = "sudo"
+                shell               = "/bin/bash"
+                ssh_authorized_keys = [file(var.public_key)]
+            }]
+            disable_root     = true
+            timezone         = "Europe/Moscow"
+            package_update   = false
+            manage_etc_hosts = "localhost"
+            fqdn             = "gitlab"
+        })
+    }
+
+    part {
+        content_type = "text/x-shellscript"
+        filename     = "install-gitlab.sh"
+        content      = <<-EOT
+            #!/bin/sh
+            apt-get -y update
+            apt-get install -y curl openssh-server ca-certificates tzdata perl
+            wget https://packages.gitlab.com/install/repositories/gitlab/gitlab-ee/script.deb.sh
+            bash ./script.deb.sh
+            EXTERNAL_URL=$(curl -s -4 -m 10 https://digitalresistance.dog/myIp) GITLAB_ROOT_PASSWORD="${random_password.password.result}" apt-get install gitlab-ee
+        EOT
     }
 }
 
@@ -85,7 +108,7 @@ resource "rustack_vm" "gitlab" {
 
     template_id = data.rustack_template.ubuntu20.id
 
-    user_data = data.template_file.cloud_init.rendered
+    user_data = data.rustack_cloudinit_config.cloud_init.rendered
 
     disk {
         name = "Root"
@@ -16,8 +16,6 @@ provider "rustack" {
 
 provider "random" {}
 
-provider "template" {}
-
 resource "rustack_project" "demo_project" {
     name = "Terraform Demo"
 }
@@ -82,34 +80,52 @@ resource "random_password" "password" {
     override_special = "_-#"
 }
 
-# locals {
-#   expanded_names = [
-#       for i in range(var.nodes_count) : format("host-%s", i)
-#   ]
-# }
-
+locals {
+    cloud_config = {
+        debug = { verbose = true }
+        users = [{
+            name   = "debian"
+            sudo   = ["ALL=(ALL) NOPASSWD:ALL"]
+            groups = "sudo"
+            shell  = "/bin/bash"
+        }]
+        chpasswd = {
+            list   = ["debian:${random_password.password.result}"]
+            expire = false
+        }
+        runcmd = [
+            "apt-get -y update",
+            "apt-get -y install nginx",
+        ]
+    }
+}
 
-data "template_file" "cloud_init_node" {
+data "rustack_cloudinit_config" "cloud_init_node" {
     count = var.nodes_count
 
-    template = file("cloud-config-node.tpl")
-    vars = {
-        vm_password   = random_password.password.result
-        hostname      = format("host-%s", count.index)
+    part {
+        content = yamlencode(merge(local.cloud_config, {
+            fqdn   = format("host-%s", count.index)
+            runcmd = concat(local.cloud_config.runcmd, [
+                "echo '<h1>Hello, World!</h1><code>Node ${format("host-%s", count.index)}</code>' > /var/www/html/index.nginx-debian.html",
+            ])
+        }))
     }
 }
 
 
 resource "rustack_vm" "vm_node" {
+    count = var.nodes_count
+
     vdc_id = rustack_vdc.vdc1.id
 
-    name = "Host 1"
+    name = format("Host %d", count.index + 1)
     cpu = 2
     ram = 4
 
     template_id = data.rustack_template.debian10.id
 
-    user_data = element(data.template_file.cloud_init_node.*.rendered, 1) 
+    user_data = data.rustack_cloudinit_config.cloud_init_node[count.index].rendered
 
     disk {
         name = "Root disk"
@@ -128,15 +144,32 @@ resource "rustack_vm" "vm_node" {
 }
 
 
-data "template_file" "cloud_init_master" {
-    template = file("cloud-config-balancer.tpl")
-    vars = {
-        vm_password   = random_password.password.result
-        hostname      = "balancer"
-        balancer_upstream = <<-EOT
-            %{ for k, v in rustack_vm.vm_node ~}
-            server ${v.port.0.ip_address}:80;
+data "rustack_cloudinit_config" "cloud_init_master" {
+    part {
+        content = yamlencode(merge(local.cloud_config, {
+            fqdn = "balancer"
+        }))
+    }
+
+    write_file {
+        path        = "/etc/nginx/sites-enabled/default"
+        permissions = "0644"
+        content     = <<-EOT
+            upstream demo {
+            %{ for v in rustack_vm.vm_node ~}
+              server ${v.port.0.ip_address}:80;
             %{ endfor ~}
+            }
+            server {
+              listen 80 default_server;
+              root /var/www/html;
+              server_name _;
+              location / {
+                proxy_pass http://demo;
+                proxy_read_timeout     120;
+                proxy_connect_timeout  120;
+              }
+            }
         EOT
     }
 }
@@ -150,7 +183,7 @@ resource "rustack_vm" "vm_master" {
 
     template_id = data.rustack_template.debian10.id
 
-    user_data = data.template_file.cloud_init_master.rendered
+    user_data = data.rustack_cloudinit_config.cloud_init_master.rendered
 
     disk {
         name = "Root disk"
@@ -0,0 +1,151 @@
+package rustack_terraform
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pkg/errors"
+)
+
+const defaultSharedCredentialsFile = "~/.rustack/credentials"
+
+// tokenCommandTimeout bounds the time token_command may take to print a token.
+var tokenCommandTimeout = time.Minute
+
+// resolveToken finds the API token. The first configured source wins:
+// token, token_file, token_command and finally the profile from the shared
+// credentials file. The name of the used source is returned with the token.
+func resolveToken(ctx context.Context, d *schema.ResourceData) (token string, source string, err error) {
+	if v, ok := d.GetOk("token"); ok {
+		return strings.TrimSpace(v.(string)), "token", nil
+	}
+
+	if v, ok := d.GetOk("token_file"); ok {
+		token, err = readTokenFile(v.(string))
+		return token, "token_file", err
+	}
+
+	if v, ok := d.GetOk("token_command"); ok {
+		token, err = runTokenCommand(ctx, v.(string))
+		return token, "token_command", err
+	}
+
+	path := d.Get("shared_credentials_file").(string)
+	profile, explicit := d.GetOk("profile")
+	if !explicit {
+		profile = "default"
+	}
+	source = fmt.Sprintf("profile %q", profile)
+	token, err = readProfileToken(path, profile.(string))
+	if os.IsNotExist(errors.Cause(err)) && !explicit {
+		return "", "", errors.New("No API token configured: set token, token_file, token_command " +
+			"or a profile in the shared credentials file")
+	}
+	return token, source, err
+}
+
+func readTokenFile(path string) (string, error) {
+	raw, err := os.ReadFile(expandHome(path))
+	if err != nil {
+		return "", errors.Wrap(err, "token_file: Error reading the token")
+	}
+	token := strings.TrimSpace(string(raw))
+	if token == "" {
+		return "", fmt.Errorf("token_file: %s is empty", path)
+	}
+	return token, nil
+}
+
+// runTokenCommand runs the command through the shell and returns what it
+// prints to stdout, e.g. a short-lived token issued by a secret store.
+func runTokenCommand(ctx context.Context, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, tokenCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("token_command: Command did not finish within %s", tokenCommandTimeout)
+		}
+		return "", fmt.Errorf("token_command: Command failed: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	token := strings.TrimSpace(stdout.String())
+	if token == "" {
+		return "", errors.New("token_command: Command printed no token")
+	}
+	return token, nil
+}
+
+// readProfileToken reads the token of a profile from an INI style
+// credentials file:
+//
+//	[default]
+//	token = ...
+func readProfileToken(path, profile string) (string, error) {
+	file, err := os.Open(expandHome(path))
+	if err != nil {
+		return "", errors.Wrap(err, "shared_credentials_file: Error reading credentials")
+	}
+	defer file.Close()
+
+	section := ""
+	found := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			found = found || section == profile
+			continue
+		}
+		if section != profile {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if ok && strings.TrimSpace(key) == "token" {
+			if token := strings.TrimSpace(value); token != "" {
+				return token, nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", errors.Wrap(err, "shared_credentials_file: Error reading credentials")
+	}
+
+	if !found {
+		return "", fmt.Errorf("profile: Profile %q not found in %s", profile, path)
+	}
+	return "", fmt.Errorf("profile: Profile %q in %s has no token", profile, path)
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
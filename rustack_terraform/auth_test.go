@@ -0,0 +1,103 @@
+package rustack_terraform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// testAuthData returns provider data with the given attributes, ignoring
+// credentials from the environment of the test run.
+func testAuthData(t *testing.T, raw map[string]interface{}) *schema.ResourceData {
+	for _, env := range []string{"RUSTACK_TOKEN", "RUSTACK_TOKEN_FILE", "RUSTACK_TOKEN_COMMAND", "RUSTACK_PROFILE"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("RUSTACK_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "missing"))
+	return schema.TestResourceDataRaw(t, Provider().Schema, raw)
+}
+
+func writeTestFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "credentials")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const testCredentials = `
+# CI credentials
+[default]
+token = default-token
+
+[ci]
+token = ci-token
+
+[empty]
+`
+
+func TestResolveToken(t *testing.T) {
+	tokenFile := writeTestFile(t, "file-token\n")
+	credentials := writeTestFile(t, testCredentials)
+
+	cases := []struct {
+		name   string
+		raw    map[string]interface{}
+		token  string
+		source string
+	}{
+		{"token", map[string]interface{}{"token": "static-token", "token_file": tokenFile}, "static-token", "token"},
+		{"token_file", map[string]interface{}{"token_file": tokenFile, "profile": "ci"}, "file-token", "token_file"},
+		{"token_command", map[string]interface{}{"token_command": "echo command-token", "profile": "ci"}, "command-token", "token_command"},
+		{"default profile", map[string]interface{}{"shared_credentials_file": credentials}, "default-token", `profile "default"`},
+		{"named profile", map[string]interface{}{"shared_credentials_file": credentials, "profile": "ci"}, "ci-token", `profile "ci"`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, ok := c.raw["token_command"]; ok && runtime.GOOS == "windows" {
+				t.Skip("the test command needs a POSIX shell")
+			}
+			token, source, err := resolveToken(context.Background(), testAuthData(t, c.raw))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if token != c.token || source != c.source {
+				t.Errorf("got token %q from %s, want %q from %s", token, source, c.token, c.source)
+			}
+		})
+	}
+}
+
+func TestResolveToken_errors(t *testing.T) {
+	credentials := writeTestFile(t, testCredentials)
+
+	cases := map[string]struct {
+		raw      map[string]interface{}
+		expected string
+	}{
+		"nothing configured": {map[string]interface{}{}, "No API token configured"},
+		"missing token file": {map[string]interface{}{"token_file": "/nonexistent/token"}, "token_file: Error reading the token"},
+		"empty token file":   {map[string]interface{}{"token_file": writeTestFile(t, " \n")}, "is empty"},
+		"unknown profile":    {map[string]interface{}{"shared_credentials_file": credentials, "profile": "prod"}, `Profile "prod" not found`},
+		"profile no token":   {map[string]interface{}{"shared_credentials_file": credentials, "profile": "empty"}, `Profile "empty"`},
+		"missing file":       {map[string]interface{}{"profile": "ci"}, "shared_credentials_file: Error reading credentials"},
+		"failing command":    {map[string]interface{}{"token_command": "echo denied >&2; exit 1"}, "Command failed: exit status 1: denied"},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, ok := c.raw["token_command"]; ok && runtime.GOOS == "windows" {
+				t.Skip("the test command needs a POSIX shell")
+			}
+			_, _, err := resolveToken(context.Background(), testAuthData(t, c.raw))
+			if err == nil || !strings.Contains(err.Error(), c.expected) {
+				t.Errorf("expected an error containing %q, got %v", c.expected, err)
+			}
+		})
+	}
+}
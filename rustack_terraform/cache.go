@@ -0,0 +1,158 @@
+package rustack_terraform
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// cachedKinds are the API collections that change rarely during a run and
+// are read again and again by the resources inside them: every VM in a VDC
+// reads the VDC, its project with the hypervisors, storage profiles and
+// templates.
+var cachedKinds = map[string]bool{
+	"project":             true,
+	"vdc":                 true,
+	"storage_profile":     true,
+	"template":            true,
+	"platform":            true,
+	"firewall":            true,
+	"kubernetes_template": true,
+}
+
+// containerKinds hold objects of other kinds, so a change to one of them,
+// e.g. a deleted project, drops the whole cache.
+var containerKinds = map[string]bool{
+	"project": true,
+	"vdc":     true,
+}
+
+type cachedResponse struct {
+	kind       string
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+// lookupCache keeps the API responses of read-mostly objects for the run of
+// one provider process. Any create, update or delete of a kind drops its
+// cached responses. Every caller decodes its own copy of the body, so the
+// objects returned by rcp-go are never shared between resources.
+type lookupCache struct {
+	mu         sync.Mutex
+	entries    map[string]cachedResponse
+	generation uint64
+}
+
+func newLookupCache() *lookupCache {
+	return &lookupCache{entries: map[string]cachedResponse{}}
+}
+
+func (c *lookupCache) get(key string) (cachedResponse, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	return entry, c.generation, ok
+}
+
+// put stores a response unless the cache was invalidated after the request
+// was sent, when the response may already be outdated.
+func (c *lookupCache) put(key string, generation uint64, entry cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		c.entries[key] = entry
+	}
+}
+
+func (c *lookupCache) invalidate(kind string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for key, entry := range c.entries {
+		if containerKinds[kind] || entry.kind == kind {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// apiKind returns the collection of an API path, e.g. vdc for
+// /v1/vdc/<id>/networks.
+func apiKind(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range parts {
+		if part == "v1" && i+1 < len(parts) {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
+
+type skipLookupCacheKey struct{}
+
+// withoutLookupCache marks requests made with ctx to always reach the API,
+// e.g. when polling an object for a state change.
+func withoutLookupCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipLookupCacheKey{}, true)
+}
+
+// cacheTransport answers repeated GET requests of cached kinds from the
+// lookup cache and invalidates it on every other request.
+type cacheTransport struct {
+	next  http.RoundTripper
+	cache *lookupCache
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	kind := apiKind(req.URL.Path)
+	if req.Method != http.MethodGet {
+		resp, err := t.next.RoundTrip(req)
+		t.cache.invalidate(kind)
+		return resp, err
+	}
+
+	if !cachedKinds[kind] || req.Context().Value(skipLookupCacheKey{}) != nil {
+		return t.next.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	entry, generation, ok := t.cache.get(key)
+	if ok {
+		logTrace(req.Context(), subsystemHTTP, "Rustack API response served from the lookup cache", map[string]interface{}{
+			"method": req.Method,
+			"path":   req.URL.Path,
+		})
+		return &http.Response{
+			Status:        http.StatusText(entry.statusCode),
+			StatusCode:    entry.statusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        entry.header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(entry.body)),
+			ContentLength: int64(len(entry.body)),
+			Request:       req,
+		}, nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	t.cache.put(key, generation, cachedResponse{
+		kind:       kind,
+		statusCode: resp.StatusCode,
+		header:     resp.Header.Clone(),
+		body:       body,
+	})
+	return resp, nil
+}
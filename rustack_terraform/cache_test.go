@@ -0,0 +1,110 @@
+package rustack_terraform
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testCacheClient returns a client with a lookup cache in front of a server
+// that counts the requests it receives by path.
+func testCacheClient(t *testing.T, status int) (*http.Client, string, map[string]int) {
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.Method+" "+r.URL.Path]++
+		w.WriteHeader(status)
+		w.Write([]byte(`{"id":"1"}`))
+	}))
+	t.Cleanup(server.Close)
+	client := &http.Client{Transport: &cacheTransport{next: http.DefaultTransport, cache: newLookupCache()}}
+	return client, server.URL, requests
+}
+
+func testCacheRequest(t *testing.T, client *http.Client, ctx context.Context, method, url string) string {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestCacheTransport(t *testing.T) {
+	client, url, requests := testCacheClient(t, http.StatusOK)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if body := testCacheRequest(t, client, ctx, "GET", url+"/v1/vdc/1"); body != `{"id":"1"}` {
+			t.Errorf("unexpected body %q", body)
+		}
+		testCacheRequest(t, client, ctx, "GET", url+"/v1/vm/1")
+	}
+	if requests["GET /v1/vdc/1"] != 1 {
+		t.Errorf("expected the vdc to be read once, got %d requests", requests["GET /v1/vdc/1"])
+	}
+	if requests["GET /v1/vm/1"] != 3 {
+		t.Errorf("expected the vm not to be cached, got %d requests", requests["GET /v1/vm/1"])
+	}
+
+	testCacheRequest(t, client, withoutLookupCache(ctx), "GET", url+"/v1/vdc/1")
+	if requests["GET /v1/vdc/1"] != 2 {
+		t.Errorf("expected the request without cache to reach the API, got %d requests", requests["GET /v1/vdc/1"])
+	}
+}
+
+func TestCacheTransport_invalidate(t *testing.T) {
+	cases := map[string]struct {
+		mutation string
+		reads    int
+	}{
+		"same kind":      {"/v1/template/2", 2},
+		"container kind": {"/v1/vdc/1", 2},
+		"other kind":     {"/v1/vm/1", 1},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			client, url, requests := testCacheClient(t, http.StatusOK)
+			ctx := context.Background()
+
+			testCacheRequest(t, client, ctx, "GET", url+"/v1/template/1")
+			testCacheRequest(t, client, ctx, "PUT", url+c.mutation)
+			testCacheRequest(t, client, ctx, "GET", url+"/v1/template/1")
+			if requests["GET /v1/template/1"] != c.reads {
+				t.Errorf("expected %d reads after a change of %s, got %d", c.reads, c.mutation, requests["GET /v1/template/1"])
+			}
+		})
+	}
+}
+
+func TestCacheTransport_errorsNotCached(t *testing.T) {
+	client, url, requests := testCacheClient(t, http.StatusNotFound)
+	for i := 0; i < 2; i++ {
+		testCacheRequest(t, client, context.Background(), "GET", url+"/v1/project/1")
+	}
+	if requests["GET /v1/project/1"] != 2 {
+		t.Errorf("expected the error not to be cached, got %d requests", requests["GET /v1/project/1"])
+	}
+}
+
+func TestApiKind(t *testing.T) {
+	cases := map[string]string{
+		"/v1/vdc/1":               "vdc",
+		"/api/v1/firewall/1/rule": "firewall",
+		"v1/kubernetes_template":  "kubernetes_template",
+		"/v1":                     "",
+		"/healthz":                "",
+	}
+	for path, expected := range cases {
+		if kind := apiKind(path); kind != expected {
+			t.Errorf("apiKind(%q) = %q, want %q", path, kind, expected)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package rustack_terraform
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	cloudConfigContentType = "text/cloud-config"
+	cloudConfigHeader      = "#cloud-config"
+	// cloudConfigMergeType makes cloud-init merge the lists and maps of the
+	// generated part with the parts of the user instead of replacing them.
+	cloudConfigMergeType = "list(append)+dict(recurse_array)+str()"
+)
+
+var octalPermissionsRegexp = regexp.MustCompile(`^[0-7]{3,4}$`)
+
+// cloudinitPart is a single part of a multi-part cloud-init user data.
+type cloudinitPart struct {
+	ContentType string
+	Content     string
+	Filename    string
+	MergeType   string
+}
+
+// cloudinitWriteFile is an entry of the write_files module of cloud-config.
+type cloudinitWriteFile struct {
+	Path        string `yaml:"path"`
+	Content     string `yaml:"content"`
+	Permissions string `yaml:"permissions,omitempty"`
+	Owner       string `yaml:"owner,omitempty"`
+}
+
+// cloudinitGeneratedConfig is the cloud-config built from the structured
+// arguments of rustack_cloudinit_config.
+type cloudinitGeneratedConfig struct {
+	SshAuthorizedKeys []string             `yaml:"ssh_authorized_keys,omitempty"`
+	WriteFiles        []cloudinitWriteFile `yaml:"write_files,omitempty"`
+}
+
+// validateCloudConfig checks that a cloud-config part is a YAML mapping, the
+// way cloud-init reads it.
+func validateCloudConfig(content string) error {
+	var config map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+		return errors.Wrap(err, "Invalid cloud-config YAML")
+	}
+	return nil
+}
+
+// renderCloudinit renders the parts into user data for cloud-init. A single
+// part is rendered as is, several parts as a MIME multi-part archive.
+func renderCloudinit(parts []cloudinitPart, boundary string, gzipped, base64Encoded bool) (string, error) {
+	if len(parts) == 0 {
+		return "", errors.New("Expected at least one part")
+	}
+
+	var rendered bytes.Buffer
+	if len(parts) == 1 {
+		content := parts[0].Content
+		if parts[0].ContentType == cloudConfigContentType && !strings.HasPrefix(content, cloudConfigHeader) {
+			content = cloudConfigHeader + "\n" + content
+		}
+		rendered.WriteString(content)
+	} else {
+		fmt.Fprintf(&rendered, "Content-Type: multipart/mixed; boundary=%q\r\nMIME-Version: 1.0\r\n\r\n", boundary)
+		writer := multipart.NewWriter(&rendered)
+		if err := writer.SetBoundary(boundary); err != nil {
+			return "", errors.Wrap(err, "boundary")
+		}
+		for _, part := range parts {
+			header := textproto.MIMEHeader{}
+			header.Set("Content-Type", part.ContentType)
+			header.Set("Content-Transfer-Encoding", "7bit")
+			header.Set("MIME-Version", "1.0")
+			if part.Filename != "" {
+				header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", part.Filename))
+			}
+			if part.MergeType != "" {
+				header.Set("Merge-Type", part.MergeType)
+			}
+			w, err := writer.CreatePart(header)
+			if err != nil {
+				return "", err
+			}
+			if _, err := w.Write([]byte(part.Content)); err != nil {
+				return "", err
+			}
+		}
+		if err := writer.Close(); err != nil {
+			return "", err
+		}
+	}
+
+	if !gzipped {
+		if base64Encoded {
+			return base64.StdEncoding.EncodeToString(rendered.Bytes()), nil
+		}
+		return rendered.String(), nil
+	}
+
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	if _, err := w.Write(rendered.Bytes()); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(compressed.Bytes()), nil
+}
+
+func (args *Arguments) injectContextCloudinitConfig() {
+	args.merge(Arguments{
+		"gzip": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "compress the rendered user data with gzip. Requires base64_encode",
+		},
+		"base64_encode": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "encode the rendered user data with base64",
+		},
+		"boundary": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "MIMEBOUNDARY",
+			ValidateFunc: validation.StringLenBetween(1, 70),
+			Description:  "boundary of the MIME multi-part archive",
+		},
+		"ssh_authorized_keys": {
+			Type:         schema.TypeList,
+			Optional:     true,
+			AtLeastOneOf: []string{"ssh_authorized_keys", "write_file", "part"},
+			Description:  "SSH public keys authorized for the default user, e.g. the public_key of rustack_pub_key",
+			Elem:         &schema.Schema{Type: schema.TypeString},
+		},
+		"write_file": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Description: "files written by cloud-init",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"path": {
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "absolute path of the file",
+					},
+					"content": {
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "content of the file",
+					},
+					"permissions": {
+						Type:     schema.TypeString,
+						Optional: true,
+						ValidateFunc: validation.StringMatch(
+							octalPermissionsRegexp, "expected octal permissions, e.g. 0644",
+						),
+						Description: "octal permissions of the file, e.g. 0644",
+					},
+					"owner": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "owner of the file, e.g. root:root",
+					},
+				},
+			},
+		},
+		"part": {
+			Type:        schema.TypeList,
+			Optional:    true,
+			Description: "parts of the user data, in the order cloud-init processes them",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"content_type": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						Default:     cloudConfigContentType,
+						Description: "MIME type of the part, e.g. text/cloud-config or text/x-shellscript",
+					},
+					"content": {
+						Type:        schema.TypeString,
+						Required:    true,
+						Description: "content of the part",
+					},
+					"filename": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "file name of the part",
+					},
+					"merge_type": {
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "how cloud-init merges the part with the previous cloud-config parts",
+					},
+				},
+			},
+		},
+	})
+}
+
+func (args *Arguments) injectResultCloudinitConfig() {
+	args.merge(Arguments{
+		"rendered": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "rendered user data for the user_data of rustack_vm",
+		},
+	})
+}
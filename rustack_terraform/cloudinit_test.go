@@ -0,0 +1,171 @@
+package rustack_terraform
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestRenderCloudinit_singlePart(t *testing.T) {
+	rendered, err := renderCloudinit([]cloudinitPart{{ContentType: cloudConfigContentType, Content: "fqdn: test\n"}}, "MIMEBOUNDARY", false, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if rendered != "#cloud-config\nfqdn: test\n" {
+		t.Errorf("rendered = %q, want the cloud-config with its header", rendered)
+	}
+
+	rendered, err = renderCloudinit([]cloudinitPart{{ContentType: "text/x-shellscript", Content: "#!/bin/sh\necho ok\n"}}, "MIMEBOUNDARY", false, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if rendered != "#!/bin/sh\necho ok\n" {
+		t.Errorf("rendered = %q, want the script as is", rendered)
+	}
+}
+
+func TestRenderCloudinit_multiPart(t *testing.T) {
+	parts := []cloudinitPart{
+		{ContentType: cloudConfigContentType, Content: "#cloud-config\nfqdn: test\n", MergeType: cloudConfigMergeType},
+		{ContentType: "text/x-shellscript", Content: "#!/bin/sh\necho ok\n", Filename: "setup.sh"},
+	}
+	rendered, err := renderCloudinit(parts, "MIMEBOUNDARY", true, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(rendered)
+	if err != nil {
+		t.Fatalf("rendered user data is not base64: %s", err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("rendered user data is not gzipped: %s", err)
+	}
+	message, err := mail.ReadMessage(r)
+	if err != nil {
+		t.Fatalf("rendered user data is not a MIME message: %s", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q, want multipart/mixed", message.Header.Get("Content-Type"))
+	}
+
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	for i, want := range parts {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("part %d: %s", i, err)
+		}
+		content, _ := io.ReadAll(part)
+		if part.Header.Get("Content-Type") != want.ContentType || string(content) != want.Content {
+			t.Errorf("part %d = %s %q, want %s %q", i, part.Header.Get("Content-Type"), content, want.ContentType, want.Content)
+		}
+		if part.Header.Get("Merge-Type") != want.MergeType || part.FileName() != want.Filename {
+			t.Errorf("part %d has merge type %q and file name %q, want %q and %q",
+				i, part.Header.Get("Merge-Type"), part.FileName(), want.MergeType, want.Filename)
+		}
+	}
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("expected %d parts, got more", len(parts))
+	}
+}
+
+func TestValidateCloudConfig(t *testing.T) {
+	if err := validateCloudConfig("#cloud-config\nruncmd:\n- echo ok\n"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	for _, content := range []string{"runcmd: [unterminated", "- a list"} {
+		if err := validateCloudConfig(content); err == nil {
+			t.Errorf("expected an error for %q", content)
+		}
+	}
+}
+
+func TestAccRustackCloudinitConfig_basic(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: api.providerConfig() + `
+data "rustack_account" "me" {}
+
+data "rustack_pub_key" "key" {
+  account_id = data.rustack_account.me.id
+  name       = "terraform"
+}
+
+data "rustack_cloudinit_config" "test" {
+  ssh_authorized_keys = [data.rustack_pub_key.key.public_key]
+
+  write_file {
+    path        = "/etc/motd"
+    content     = "managed by terraform\n"
+    permissions = "0644"
+  }
+
+  part {
+    content = yamlencode({ fqdn = "terraform-acc" })
+  }
+
+  part {
+    content_type = "text/x-shellscript"
+    content      = "#!/bin/sh\necho ok\n"
+  }
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrWith("data.rustack_cloudinit_config.test", "rendered", func(rendered string) error {
+						for _, want := range []string{
+							"Content-Type: multipart/mixed",
+							"ssh_authorized_keys:\n- ssh-ed25519 AAAA terraform",
+							"path: /etc/motd",
+							`"terraform-acc"`,
+							"Content-Type: text/x-shellscript",
+						} {
+							if !strings.Contains(rendered, want) {
+								return fmt.Errorf("expected the rendered user data to contain %q, got:\n%s", want, rendered)
+							}
+						}
+						return nil
+					}),
+				),
+			},
+			{
+				Config: api.providerConfig() + `
+data "rustack_cloudinit_config" "test" {
+  part {
+    content = "runcmd: [unterminated"
+  }
+}
+`,
+				ExpectError: regexp.MustCompile(`part.0.content: Invalid cloud-config YAML`),
+			},
+			{
+				Config: api.providerConfig() + `
+data "rustack_cloudinit_config" "test" {
+  gzip = true
+
+  part {
+    content = "fqdn: test"
+  }
+}
+`,
+				ExpectError: regexp.MustCompile(`gzip: Compressed user data must be base64 encoded`),
+			},
+		},
+	})
+}
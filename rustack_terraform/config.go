@@ -1,38 +1,84 @@
 package rustack_terraform
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/rustack-cloud-platform/rcp-go/rustack"
-	"github.com/sirupsen/logrus"
 )
 
 type Config struct {
-	Token            string
-	APIEndpoint      string
-	TerraformVersion string
-	ClientID         string
+	Token                     string
+	TokenSource               string
+	APIEndpoint               string
+	TerraformVersion          string
+	ClientID                  string
+	Retry                     RetryPolicy
+	SkipCredentialsValidation bool
+	CAFile                    string
+	ClientCertFile            string
+	ClientKeyFile             string
+	InsecureSkipVerify        bool
+	ProxyURL                  string
+	RequestTimeout            time.Duration
+	MaxRequestsPerSecond      float64
+	MaxConcurrentVdcChanges   int
+	DefaultTags               []string
+	DefaultProjectID          string
+	DefaultProjectName        string
+	DefaultVdcID              string
+	DefaultVdcName            string
 }
 
 type CombinedConfig struct {
-	manager *rustack.Manager
+	manager          *rustack.Manager
+	defaultTags      []string
+	defaultProjectID string
+	defaultVdcID     string
+	mutations        *mutationQueue
 }
 
 func (c *CombinedConfig) rustackManager() *rustack.Manager { return c.manager }
 
-func (c *Config) Client() (*CombinedConfig, diag.Diagnostics) {
-	logger := logrus.New()
-	logger.SetLevel(logrus.DebugLevel)
+func (c *Config) Client(ctx context.Context) (*CombinedConfig, diag.Diagnostics) {
+	client, diags := c.newHTTPClient()
+	if diags.HasError() {
+		return nil, diags
+	}
+	client.Transport = &cacheTransport{next: client.Transport, cache: newLookupCache()}
 
 	manager := rustack.NewManager(c.Token)
-	manager.Logger = logger
+	manager.Client = client
 	manager.BaseURL = strings.TrimSuffix(c.APIEndpoint, "/")
 	manager.ClientID = c.ClientID
 	manager.UserAgent = fmt.Sprintf("Terraform/%s", c.TerraformVersion)
 
+	// Fail fast on a bad token instead of on the first resource
+	if !c.SkipCredentialsValidation {
+		account, err := manager.WithContext(ctx).GetAccount()
+		if err != nil {
+			return nil, append(diags, apiErrorf("Error validating the API token from %s: %s", c.TokenSource, err)...)
+		}
+		logInfo(ctx, subsystemAuth, "Authenticated", map[string]interface{}{
+			"username":     account.Username,
+			"token_source": c.TokenSource,
+		})
+	}
+
+	defaultProjectID, defaultVdcID, defaultDiags := c.resolveDefaultContext(manager.WithContext(ctx))
+	diags = append(diags, defaultDiags...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
 	return &CombinedConfig{
-		manager: manager,
-	}, nil
+		manager:          manager,
+		defaultTags:      c.DefaultTags,
+		defaultProjectID: defaultProjectID,
+		defaultVdcID:     defaultVdcID,
+		mutations:        newMutationQueue(c.MaxConcurrentVdcChanges),
+	}, diags
 }
@@ -31,11 +31,11 @@ func dataSourceRustackAccount() *schema.Resource {
 }
 
 func dataSourceRustackAccountRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	account, err := manager.GetAccount()
 
 	if err != nil {
-		return diag.Errorf("Error retrieving account: %s", err)
+		return apiErrorf("Error retrieving account: %s", err)
 	}
 
 	d.SetId(account.ID)
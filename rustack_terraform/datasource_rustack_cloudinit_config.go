@@ -0,0 +1,86 @@
+package rustack_terraform
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"gopkg.in/yaml.v2"
+)
+
+func dataSourceRustackCloudinitConfig() *schema.Resource {
+	args := Defaults()
+	args.injectContextCloudinitConfig()
+	args.injectResultCloudinitConfig()
+
+	return &schema.Resource{
+		ReadContext: dataSourceRustackCloudinitConfigRead,
+		Schema:      args,
+	}
+}
+
+func dataSourceRustackCloudinitConfigRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	gzipped := d.Get("gzip").(bool)
+	base64Encoded := d.Get("base64_encode").(bool)
+	if gzipped && !base64Encoded {
+		return diag.Errorf("gzip: Compressed user data must be base64 encoded, set base64_encode = true")
+	}
+
+	parts := make([]cloudinitPart, 0)
+
+	var generated cloudinitGeneratedConfig
+	for _, key := range d.Get("ssh_authorized_keys").([]interface{}) {
+		if key == nil {
+			return diag.Errorf("ssh_authorized_keys: Expected SSH public keys, got an empty one")
+		}
+		generated.SshAuthorizedKeys = append(generated.SshAuthorizedKeys, key.(string))
+	}
+	for _, value := range d.Get("write_file").([]interface{}) {
+		file := value.(map[string]interface{})
+		generated.WriteFiles = append(generated.WriteFiles, cloudinitWriteFile{
+			Path:        file["path"].(string),
+			Content:     file["content"].(string),
+			Permissions: file["permissions"].(string),
+			Owner:       file["owner"].(string),
+		})
+	}
+	if len(generated.SshAuthorizedKeys) > 0 || len(generated.WriteFiles) > 0 {
+		content, err := yaml.Marshal(generated)
+		if err != nil {
+			return diag.Errorf("Error rendering cloud-config: %s", err)
+		}
+		parts = append(parts, cloudinitPart{
+			ContentType: cloudConfigContentType,
+			Content:     cloudConfigHeader + "\n" + string(content),
+			MergeType:   cloudConfigMergeType,
+		})
+	}
+
+	for i, value := range d.Get("part").([]interface{}) {
+		part := value.(map[string]interface{})
+		contentType := part["content_type"].(string)
+		content := part["content"].(string)
+		if contentType == cloudConfigContentType {
+			if err := validateCloudConfig(content); err != nil {
+				return diag.Errorf("part.%d.content: %s", i, err)
+			}
+		}
+		parts = append(parts, cloudinitPart{
+			ContentType: contentType,
+			Content:     content,
+			Filename:    part["filename"].(string),
+			MergeType:   part["merge_type"].(string),
+		})
+	}
+
+	rendered, err := renderCloudinit(parts, d.Get("boundary").(string), gzipped, base64Encoded)
+	if err != nil {
+		return diag.Errorf("Error rendering user data: %s", err)
+	}
+
+	d.Set("rendered", rendered)
+	d.SetId(fmt.Sprintf("%x", sha256.Sum256([]byte(rendered))))
+	return nil
+}
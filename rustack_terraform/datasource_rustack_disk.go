@@ -21,26 +21,26 @@ func dataSourceRustackDisk() *schema.Resource {
 }
 
 func dataSourceRustackDiskRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	target, err := checkDatasourceNameOrId(d)
 	if err != nil {
-		return diag.Errorf("Error getting disk: %s", err)
+		return apiErrorf("Error getting disk: %s", err)
 	}
 	var targetDisk *rustack.Disk
 	if target == "id" {
 		targetDisk, err = manager.GetDisk(d.Get("id").(string))
 		if err != nil {
-			return diag.Errorf("Error getting disk: %s", err)
+			return apiErrorf("Error getting disk: %s", err)
 		}
 	} else {
 		targetDisk, err = GetDiskByName(d, manager, targetVdc)
 		if err != nil {
-			return diag.Errorf("Error getting disk: %s", err)
+			return apiErrorf("Error getting disk: %s", err)
 		}
 	}
 
@@ -54,7 +54,7 @@ func dataSourceRustackDiskRead(ctx context.Context, d *schema.ResourceData, meta
 	}
 
 	if err := setResourceDataFromMap(d, flatten); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 
 	d.SetId(targetDisk.ID)
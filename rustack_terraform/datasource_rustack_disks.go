@@ -21,15 +21,15 @@ func dataSourceRustackDisks() *schema.Resource {
 }
 
 func dataSourceRustackDisksRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	allDisks, err := targetVdc.GetDisks()
 	if err != nil {
-		return diag.Errorf("Error retrieving disks: %s", err)
+		return apiErrorf("Error retrieving disks: %s", err)
 	}
 
 	flattenedRecords := make([]map[string]interface{}, len(allDisks))
@@ -45,7 +45,7 @@ func dataSourceRustackDisksRead(ctx context.Context, d *schema.ResourceData, met
 
 	hash, err := hashstructure.Hash(allDisks, hashstructure.FormatV2, nil)
 	if err != nil {
-		diag.Errorf("unable to set `disks` attribute: %s", err)
+		apiErrorf("unable to set `disks` attribute: %s", err)
 	}
 
 	d.SetId(fmt.Sprintf("disks/%d", hash))
@@ -53,7 +53,7 @@ func dataSourceRustackDisksRead(ctx context.Context, d *schema.ResourceData, met
 	// d.Set("vdc_name", nil)
 
 	if err := d.Set("disks", flattenedRecords); err != nil {
-		return diag.Errorf("unable to set `disks` attribute: %s", err)
+		return apiErrorf("unable to set `disks` attribute: %s", err)
 	}
 
 	return nil
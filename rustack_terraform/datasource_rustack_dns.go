@@ -21,22 +21,22 @@ func dataSourceRustackDns() *schema.Resource {
 }
 
 func dataSourceRustackDnsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 
 	target, err := checkDatasourceNameOrId(d)
 	if err != nil {
-		return diag.Errorf("Error getting dns: %s", err)
+		return apiErrorf("Error getting dns: %s", err)
 	}
 	var targetDns *rustack.Dns
 	if target == "id" {
 		targetDns, err = manager.GetDns(d.Get("id").(string))
 		if err != nil {
-			return diag.Errorf("Error getting dns: %s", err)
+			return apiErrorf("Error getting dns: %s", err)
 		}
 	} else {
 		targetDns, err = GetDnsByName(d, manager)
 		if err != nil {
-			return diag.Errorf("Error getting dns: %s", err)
+			return apiErrorf("Error getting dns: %s", err)
 		}
 	}
 
@@ -47,7 +47,7 @@ func dataSourceRustackDnsRead(ctx context.Context, d *schema.ResourceData, meta
 	}
 
 	if err := setResourceDataFromMap(d, flatten); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 
 	d.SetId(targetDns.ID)
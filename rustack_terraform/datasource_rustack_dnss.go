@@ -21,15 +21,15 @@ func dataSourceRustackDnss() *schema.Resource {
 }
 
 func dataSourceRustackDnssRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	project, err := GetProjectById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting project: %s", err)
+		return apiErrorf("Error getting project: %s", err)
 	}
 
 	allDns, err := project.GetDnss()
 	if err != nil {
-		return diag.Errorf("Error retrieving dnss: %s", err)
+		return apiErrorf("Error retrieving dnss: %s", err)
 	}
 
 	flattenedRecords := make([]map[string]interface{}, len(allDns))
@@ -42,13 +42,13 @@ func dataSourceRustackDnssRead(ctx context.Context, d *schema.ResourceData, meta
 
 	hash, err := hashstructure.Hash(allDns, hashstructure.FormatV2, nil)
 	if err != nil {
-		diag.Errorf("unable to set `dnss` attribute: %s", err)
+		apiErrorf("unable to set `dnss` attribute: %s", err)
 	}
 
 	d.SetId(fmt.Sprintf("dnss/%d", hash))
 
 	if err := d.Set("dnss", flattenedRecords); err != nil {
-		return diag.Errorf("unable to set `dnss` attribute: %s", err)
+		return apiErrorf("unable to set `dnss` attribute: %s", err)
 	}
 
 	return nil
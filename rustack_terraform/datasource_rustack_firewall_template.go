@@ -21,26 +21,26 @@ func dataSourceRustackFirewallTemplate() *schema.Resource {
 }
 
 func dataSourceRustackFirewallTemplateRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	target, err := checkDatasourceNameOrId(d)
 	if err != nil {
-		return diag.Errorf("Error getting template: %s", err)
+		return apiErrorf("Error getting template: %s", err)
 	}
 	var targetFirewallTemplate *rustack.FirewallTemplate
 	if target == "id" {
 		targetFirewallTemplate, err = manager.GetFirewallTemplate(d.Get("id").(string))
 		if err != nil {
-			return diag.Errorf("Error getting template: %s", err)
+			return apiErrorf("Error getting template: %s", err)
 		}
 	} else {
 		targetFirewallTemplate, err = GetFirewallTemplateByName(d, manager, targetVdc)
 		if err != nil {
-			return diag.Errorf("Error getting template: %s", err)
+			return apiErrorf("Error getting template: %s", err)
 		}
 	}
 
@@ -50,7 +50,7 @@ func dataSourceRustackFirewallTemplateRead(ctx context.Context, d *schema.Resour
 	}
 
 	if err := setResourceDataFromMap(d, flatten); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 
 	d.SetId(targetFirewallTemplate.ID)
@@ -21,28 +21,28 @@ func dataSourceRustackFirewallTemplates() *schema.Resource {
 }
 
 func dataSourceRustackFirewallTemplatesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	allFirewallTemplates, err := targetVdc.GetFirewallTemplates()
 	if err != nil {
-		return diag.Errorf("Error retrieving firewall templates: %s", err)
+		return apiErrorf("Error retrieving firewall templates: %s", err)
 	}
 
 	flattenedRecords := make([]map[string]interface{}, len(allFirewallTemplates))
 
 	hash, err := hashstructure.Hash(allFirewallTemplates, hashstructure.FormatV2, nil)
 	if err != nil {
-		diag.Errorf("unable to set `firewall_templates` attribute: %s", err)
+		apiErrorf("unable to set `firewall_templates` attribute: %s", err)
 	}
 
 	d.SetId(fmt.Sprintf("firewall_templates/%d", hash))
 
 	if err := d.Set("firewall_templates", flattenedRecords); err != nil {
-		return diag.Errorf("unable to set `firewall_templates` attribute: %s", err)
+		return apiErrorf("unable to set `firewall_templates` attribute: %s", err)
 	}
 
 	return nil
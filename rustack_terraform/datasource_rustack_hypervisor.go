@@ -21,26 +21,26 @@ func dataSourceRustackHypervisor() *schema.Resource {
 }
 
 func dataSourceRustackHypervisorRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetProject, err := GetProjectById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting project: %s", err)
+		return apiErrorf("Error getting project: %s", err)
 	}
 
 	target, err := checkDatasourceNameOrId(d)
 	if err != nil {
-		return diag.Errorf("Error getting hypervisor: %s", err)
+		return apiErrorf("Error getting hypervisor: %s", err)
 	}
 	var targetHypervisor *rustack.Hypervisor
 	if target == "id" {
 		targetHypervisor, err = GetHypervisorByIdRead(d, manager, targetProject)
 		if err != nil {
-			return diag.Errorf("Error getting hypervisor: %s", err)
+			return apiErrorf("Error getting hypervisor: %s", err)
 		}
 	} else {
 		targetHypervisor, err = GetHypervisorByName(d, manager, targetProject)
 		if err != nil {
-			return diag.Errorf("Error getting hypervisor: %s", err)
+			return apiErrorf("Error getting hypervisor: %s", err)
 		}
 	}
 
@@ -52,7 +52,7 @@ func dataSourceRustackHypervisorRead(ctx context.Context, d *schema.ResourceData
 	}
 
 	if err := setResourceDataFromMap(d, flatten); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 
 	d.SetId(targetHypervisor.ID)
@@ -21,10 +21,10 @@ func dataSourceRustackHypervisors() *schema.Resource {
 }
 
 func dataSourceRustackHypervisorsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetProject, err := GetProjectById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting project: %s", err)
+		return apiErrorf("Error getting project: %s", err)
 	}
 
 	hypervisors, err := targetProject.GetAvailableHypervisors()
@@ -44,7 +44,7 @@ func dataSourceRustackHypervisorsRead(ctx context.Context, d *schema.ResourceDat
 
 	hash, err := hashstructure.Hash(hypervisors, hashstructure.FormatV2, nil)
 	if err != nil {
-		diag.Errorf("unable to set `hypervisors` attribute: %s", err)
+		apiErrorf("unable to set `hypervisors` attribute: %s", err)
 	}
 
 	d.SetId(fmt.Sprintf("hypervisors/%d", hash))
@@ -52,7 +52,7 @@ func dataSourceRustackHypervisorsRead(ctx context.Context, d *schema.ResourceDat
 	// d.Set("project_name", nil)
 
 	if err := d.Set("hypervisors", flattenedHypervisors); err != nil {
-		return diag.Errorf("unable to set `hypervisors` attribute: %s", err)
+		return apiErrorf("unable to set `hypervisors` attribute: %s", err)
 	}
 
 	return nil
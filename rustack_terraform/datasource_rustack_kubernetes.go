@@ -12,6 +12,7 @@ import (
 func dataSourceRustackKubernetes() *schema.Resource {
 	args := Defaults()
 	args.injectResultKubernetes()
+	args.injectResultKubeconfig()
 	args.injectContextVdcById()
 	args.injectContextGetKubernetes() // override "name"
 
@@ -22,26 +23,26 @@ func dataSourceRustackKubernetes() *schema.Resource {
 }
 
 func dataSourceRustackKubernetesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	target, err := checkDatasourceNameOrId(d)
 	if err != nil {
-		return diag.Errorf("Error getting Kubernetes: %s", err)
+		return apiErrorf("Error getting Kubernetes: %s", err)
 	}
 	var targetKubernetes *rustack.Kubernetes
 	if target == "id" {
 		targetKubernetes, err = manager.GetKubernetes(d.Get("id").(string))
 		if err != nil {
-			return diag.Errorf("Error getting Kubernetes: %s", err)
+			return apiErrorf("Error getting Kubernetes: %s", err)
 		}
 	} else {
 		targetKubernetes, err = GetKubernetesByName(d, manager, targetVdc)
 		if err != nil {
-			return diag.Errorf("Error getting Kubernetes: %s", err)
+			return apiErrorf("Error getting Kubernetes: %s", err)
 		}
 	}
 
@@ -52,10 +53,19 @@ func dataSourceRustackKubernetesRead(ctx context.Context, d *schema.ResourceData
 
 	dashboard, err := targetKubernetes.GetKubernetesDashBoardUrl()
 	if err != nil {
-		return diag.Errorf("id: Error getting Kubernetes dashboard url: %s", err)
+		return apiErrorf("id: Error getting Kubernetes dashboard url: %s", err)
 	}
 	dashboard_url := fmt.Sprint(manager.BaseURL, *dashboard.DashBoardUrl)
 
+	config, err := getKubeconfig(ctx, manager, targetKubernetes.ID)
+	if err != nil {
+		return apiErrorf("kubeconfig: Error getting Kubernetes config: %s", err)
+	}
+	flatConfig, err := flattenKubeconfig(config)
+	if err != nil {
+		return diag.Errorf("kubeconfig: %s", err)
+	}
+
 	flatten := map[string]interface{}{
 		"id":                      targetKubernetes.ID,
 		"name":                    targetKubernetes.Name,
@@ -78,6 +88,9 @@ func dataSourceRustackKubernetesRead(ctx context.Context, d *schema.ResourceData
 	}
 
 	if err := setResourceDataFromMap(d, flatten); err != nil {
+		return apiErrorDiag(err)
+	}
+	if err := setResourceDataFromMap(d, flatConfig); err != nil {
 		return diag.FromErr(err)
 	}
 
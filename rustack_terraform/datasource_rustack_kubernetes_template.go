@@ -21,26 +21,26 @@ func dataSourceRustackKubernetesTemplate() *schema.Resource {
 }
 
 func dataSourceRustackKubernetesTemplateRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	target, err := checkDatasourceNameOrId(d)
 	if err != nil {
-		return diag.Errorf("Error getting KubernetesTemplate: %s", err)
+		return apiErrorf("Error getting KubernetesTemplate: %s", err)
 	}
 	var targetKubernetesTemplate *rustack.KubernetesTemplate
 	if target == "id" {
 		targetKubernetesTemplate, err = manager.GetKubernetesTemplate(d.Get("id").(string))
 		if err != nil {
-			return diag.Errorf("Error getting KubernetesTemplate: %s", err)
+			return apiErrorf("Error getting KubernetesTemplate: %s", err)
 		}
 	} else {
 		targetKubernetesTemplate, err = GetKubernetesTemplateByName(d, manager, targetVdc)
 		if err != nil {
-			return diag.Errorf("Error getting KubernetesTemplate: %s", err)
+			return apiErrorf("Error getting KubernetesTemplate: %s", err)
 		}
 	}
 
@@ -53,7 +53,7 @@ func dataSourceRustackKubernetesTemplateRead(ctx context.Context, d *schema.Reso
 	}
 
 	if err := setResourceDataFromMap(d, flatten); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 
 	d.SetId(targetKubernetesTemplate.ID)
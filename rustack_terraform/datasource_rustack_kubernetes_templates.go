@@ -21,15 +21,15 @@ func dataSourceRustackKubernetesTemplates() *schema.Resource {
 }
 
 func dataSourceRustackKubernetesTemplateReadRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	allKubernetesTemplateRead, err := targetVdc.GetKubernetesTemplates()
 	if err != nil {
-		return diag.Errorf("Error retrieving KubernetesTemplateRead: %s", err)
+		return apiErrorf("Error retrieving KubernetesTemplateRead: %s", err)
 	}
 
 	flattenedRecords := make([]map[string]interface{}, len(allKubernetesTemplateRead))
@@ -45,13 +45,13 @@ func dataSourceRustackKubernetesTemplateReadRead(ctx context.Context, d *schema.
 
 	hash, err := hashstructure.Hash(allKubernetesTemplateRead, hashstructure.FormatV2, nil)
 	if err != nil {
-		diag.Errorf("unable to set `kubernetes_templates` attribute: %s", err)
+		apiErrorf("unable to set `kubernetes_templates` attribute: %s", err)
 	}
 
 	d.SetId(fmt.Sprintf("kubernetes_templates/%d", hash))
 
 	if err := d.Set("kubernetes_templates", flattenedRecords); err != nil {
-		return diag.Errorf("unable to set `kubernetes_templates` attribute: %s", err)
+		return apiErrorf("unable to set `kubernetes_templates` attribute: %s", err)
 	}
 
 	return nil
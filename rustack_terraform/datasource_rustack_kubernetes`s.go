@@ -21,15 +21,15 @@ func dataSourceRustackKubernetess() *schema.Resource {
 }
 
 func dataSourceRustackKubernetessRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	allk8s, err := targetVdc.GetKubernetes()
 	if err != nil {
-		return diag.Errorf("Error retrieving Kubernetess: %s", err)
+		return apiErrorf("Error retrieving Kubernetess: %s", err)
 	}
 
 	flattenedRecords := make([]map[string]interface{}, len(allk8s))
@@ -37,18 +37,9 @@ func dataSourceRustackKubernetessRead(ctx context.Context, d *schema.ResourceDat
 
 		dashboard, err := targetKubernetes.GetKubernetesDashBoardUrl()
 		if err != nil {
-			return diag.Errorf("id: Error getting Kubernetes dashboard url: %s", err)
+			return apiErrorf("id: Error getting Kubernetes dashboard url: %s", err)
 		}
 		dashboard_url := fmt.Sprint(manager.BaseURL, *dashboard.DashBoardUrl)
-		err = targetKubernetes.GetKubernetesConfigUrl()
-		if err != nil {
-			return diag.Errorf("id: Error creating Kubernetes config file url: %s", err)
-		}
-
-		err = targetKubernetes.GetKubernetesConfigUrl()
-		if err != nil {
-			return diag.Errorf("id: Error creating Kubernetes config file url: %s", err)
-		}
 
 		vms := make([]*string, len(targetKubernetes.Vms))
 		for i, vm := range targetKubernetes.Vms {
@@ -78,13 +69,13 @@ func dataSourceRustackKubernetessRead(ctx context.Context, d *schema.ResourceDat
 
 	hash, err := hashstructure.Hash(allk8s, hashstructure.FormatV2, nil)
 	if err != nil {
-		diag.Errorf("unable to set `kubernetess` attribute: %s", err)
+		apiErrorf("unable to set `kubernetess` attribute: %s", err)
 	}
 
 	d.SetId(fmt.Sprintf("kubernetess/%d", hash))
 
 	if err := d.Set("kubernetess", flattenedRecords); err != nil {
-		return diag.Errorf("unable to set `kubernetess` attribute: %s", err)
+		return apiErrorf("unable to set `kubernetess` attribute: %s", err)
 	}
 
 	return nil
@@ -21,26 +21,26 @@ func dataSourceRustackLbaas() *schema.Resource {
 }
 
 func dataSourceRustackLbaasRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	target, err := checkDatasourceNameOrId(d)
 	if err != nil {
-		return diag.Errorf("Error getting Lbaas: %s", err)
+		return apiErrorf("Error getting Lbaas: %s", err)
 	}
 	var targetLbaas *rustack.LoadBalancer
 	if target == "id" {
 		targetLbaas, err = manager.GetLoadBalancer(d.Get("id").(string))
 		if err != nil {
-			return diag.Errorf("Error getting Lbaas: %s", err)
+			return apiErrorf("Error getting Lbaas: %s", err)
 		}
 	} else {
 		targetLbaas, err = GetLbaasByName(d, manager, targetVdc)
 		if err != nil {
-			return diag.Errorf("Error getting Lbaas: %s", err)
+			return apiErrorf("Error getting Lbaas: %s", err)
 		}
 	}
 
@@ -55,7 +55,7 @@ func dataSourceRustackLbaasRead(ctx context.Context, d *schema.ResourceData, met
 	}
 
 	if err := setResourceDataFromMap(d, flatten); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 
 	d.SetId(targetLbaas.ID)
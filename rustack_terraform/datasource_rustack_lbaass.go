@@ -21,15 +21,15 @@ func dataSourceRustackLoadBalancers() *schema.Resource {
 }
 
 func dataSourceRustackLoadBalancersRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	allLoadBalancers, err := targetVdc.GetLoadBalancers()
 	if err != nil {
-		return diag.Errorf("Error retrieving lbs: %s", err)
+		return apiErrorf("Error retrieving lbs: %s", err)
 	}
 
 	flattenedRecords := make([]map[string]interface{}, len(allLoadBalancers))
@@ -47,13 +47,13 @@ func dataSourceRustackLoadBalancersRead(ctx context.Context, d *schema.ResourceD
 
 	hash, err := hashstructure.Hash(allLoadBalancers, hashstructure.FormatV2, nil)
 	if err != nil {
-		diag.Errorf("unable to set `lbs` attribute: %s", err)
+		apiErrorf("unable to set `lbs` attribute: %s", err)
 	}
 
 	d.SetId(fmt.Sprintf("lbs/%d", hash))
 
 	if err := d.Set("lbaass", flattenedRecords); err != nil {
-		return diag.Errorf("unable to set `lbs` attribute: %s", err)
+		return apiErrorf("unable to set `lbs` attribute: %s", err)
 	}
 
 	return nil
@@ -21,26 +21,26 @@ func dataSourceRustackNetwork() *schema.Resource {
 }
 
 func dataSourceRustackNetworkRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	target, err := checkDatasourceNameOrId(d)
 	if err != nil {
-		return diag.Errorf("Error getting network: %s", err)
+		return apiErrorf("Error getting network: %s", err)
 	}
 	var targetNetwork *rustack.Network
 	if target == "id" {
 		targetNetwork, err = manager.GetNetwork(d.Get("id").(string))
 		if err != nil {
-			return diag.Errorf("Error getting network: %s", err)
+			return apiErrorf("Error getting network: %s", err)
 		}
 	} else {
 		targetNetwork, err = GetNetworkByName(d, manager, targetVdc)
 		if err != nil {
-			return diag.Errorf("Error getting network: %s", err)
+			return apiErrorf("Error getting network: %s", err)
 
 		}
 	}
@@ -76,7 +76,7 @@ func dataSourceRustackNetworkRead(ctx context.Context, d *schema.ResourceData, m
 	}
 
 	if err := setResourceDataFromMap(d, flatten); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 
 	d.SetId(targetNetwork.ID)
@@ -21,15 +21,15 @@ func dataSourceRustackNetworks() *schema.Resource {
 }
 
 func dataSourceRustackNetworksRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	allNetworks, err := targetVdc.GetNetworks()
 	if err != nil {
-		return diag.Errorf("Error retrieving networks: %s", err)
+		return apiErrorf("Error retrieving networks: %s", err)
 	}
 
 	flattenedRecords := make([]map[string]interface{}, len(allNetworks))
@@ -67,7 +67,7 @@ func dataSourceRustackNetworksRead(ctx context.Context, d *schema.ResourceData,
 
 	hash, err := hashstructure.Hash(allNetworks, hashstructure.FormatV2, nil)
 	if err != nil {
-		diag.Errorf("unable to set `networks` attribute: %s", err)
+		apiErrorf("unable to set `networks` attribute: %s", err)
 	}
 
 	d.SetId(fmt.Sprintf("networks/%d", hash))
@@ -75,7 +75,7 @@ func dataSourceRustackNetworksRead(ctx context.Context, d *schema.ResourceData,
 	// d.Set("vdc_name", nil)
 
 	if err := d.Set("networks", flattenedRecords); err != nil {
-		return diag.Errorf("unable to set `networks` attribute: %s", err)
+		return apiErrorf("unable to set `networks` attribute: %s", err)
 	}
 
 	return nil
@@ -32,17 +32,17 @@ func dataSourceRustackPaasTemplate() *schema.Resource {
 }
 
 func dataSourceRustackPaasTemplateRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	template, err := manager.GetPaasTemplate(d.Get("id").(int), d.Get("project_id").(string))
 	if err != nil {
-		return diag.Errorf("Error getting paas template: %s", err)
+		return apiErrorf("Error getting paas template: %s", err)
 	}
 	flatten := map[string]interface{}{
 		"id":   template.ID,
 		"name": template.Name,
 	}
 	if err := setResourceDataFromMap(d, flatten); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 	d.SetId(fmt.Sprint(template.ID))
 	return nil
@@ -21,27 +21,27 @@ func dataSourceRustackPlatform() *schema.Resource {
 }
 
 func dataSourceRustackPlatformRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	target, err := checkDatasourceNameOrId(d)
 	if err != nil {
-		return diag.Errorf("Error getting Platform: %s", err)
+		return apiErrorf("Error getting Platform: %s", err)
 	}
 	var targetPlatform *rustack.Platform
 	if target == "id" {
 		targetPlatform, err = manager.GetPlatform(d.Get("id").(string))
 		if err != nil {
-			return diag.Errorf("Error getting Platform: %s", err)
+			return apiErrorf("Error getting Platform: %s", err)
 		}
 	} else {
 		targetPlatform, err = GetPlatformByName(d, manager, targetVdc)
 		if err != nil {
-			return diag.Errorf("Error getting Platform: %s", err)
+			return apiErrorf("Error getting Platform: %s", err)
 		}
 	}
 
@@ -51,7 +51,7 @@ func dataSourceRustackPlatformRead(ctx context.Context, d *schema.ResourceData,
 	}
 
 	if err := setResourceDataFromMap(d, flatten); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 
 	d.SetId(targetPlatform.ID)
@@ -21,15 +21,15 @@ func dataSourceRustackPlatforms() *schema.Resource {
 }
 
 func dataSourceRustackPlatformsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	platforms, err := manager.GetPlatforms(targetVdc.ID)
 	if err != nil {
-		return diag.Errorf("Error retrieving platforms: %s", err)
+		return apiErrorf("Error retrieving platforms: %s", err)
 	}
 
 	flattenedRecords := make([]map[string]interface{}, len(platforms))
@@ -42,13 +42,13 @@ func dataSourceRustackPlatformsRead(ctx context.Context, d *schema.ResourceData,
 
 	hash, err := hashstructure.Hash(platforms, hashstructure.FormatV2, nil)
 	if err != nil {
-		diag.Errorf("unable to set `platforms` attribute: %s", err)
+		apiErrorf("unable to set `platforms` attribute: %s", err)
 	}
 
 	d.SetId(fmt.Sprintf("platforms/%d", hash))
 
 	if err := d.Set("platforms", flattenedRecords); err != nil {
-		return diag.Errorf("unable to set `platforms` attribute: %s", err)
+		return apiErrorf("unable to set `platforms` attribute: %s", err)
 	}
 
 	return nil
@@ -20,10 +20,10 @@ func dataSourceRustackPort() *schema.Resource {
 }
 
 func dataSourceRustackPortRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	port_id := d.Get("id")
@@ -38,12 +38,12 @@ func dataSourceRustackPortRead(ctx context.Context, d *schema.ResourceData, meta
 	if port_id != "" {
 		targetPort, err = GetPortById(d, manager, targetVdc)
 		if err != nil {
-			return diag.Errorf("Error getting port: %s", err)
+			return apiErrorf("Error getting port: %s", err)
 		}
 	} else {
 		targetPort, err = GetPortByIp(d, manager, targetVdc)
 		if err != nil {
-			return diag.Errorf("Error getting port: %s", err)
+			return apiErrorf("Error getting port: %s", err)
 		}
 
 	}
@@ -53,7 +53,7 @@ func dataSourceRustackPortRead(ctx context.Context, d *schema.ResourceData, meta
 	flatten["network"] = targetPort.Network.ID
 
 	if err := setResourceDataFromMap(d, flatten); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 
 	d.SetId(targetPort.ID)
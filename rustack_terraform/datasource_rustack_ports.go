@@ -21,15 +21,15 @@ func dataSourceRustackPorts() *schema.Resource {
 }
 
 func dataSourceRustackPortsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	allPorts, err := targetVdc.GetPorts()
 	if err != nil {
-		return diag.Errorf("Error retrieving ports: %s", err)
+		return apiErrorf("Error retrieving ports: %s", err)
 	}
 
 	flattenedRecords := make([]map[string]interface{}, len(allPorts))
@@ -43,13 +43,13 @@ func dataSourceRustackPortsRead(ctx context.Context, d *schema.ResourceData, met
 
 	hash, err := hashstructure.Hash(allPorts, hashstructure.FormatV2, nil)
 	if err != nil {
-		diag.Errorf("unable to set `ports` attribute: %s", err)
+		apiErrorf("unable to set `ports` attribute: %s", err)
 	}
 
 	d.SetId(fmt.Sprintf("port/%d", hash))
 
 	if err := d.Set("ports", flattenedRecords); err != nil {
-		return diag.Errorf("unable to set `ports` attribute: %s", err)
+		return apiErrorf("unable to set `ports` attribute: %s", err)
 	}
 
 	return nil
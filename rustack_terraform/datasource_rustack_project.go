@@ -20,22 +20,22 @@ func dataSourceRustackProject() *schema.Resource {
 }
 
 func dataSourceRustackProjectRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 
 	target, err := checkDatasourceNameOrId(d)
 	if err != nil {
-		return diag.Errorf("Error getting project: %s", err)
+		return apiErrorf("Error getting project: %s", err)
 	}
 	var targetProject *rustack.Project
 	if target == "id" {
 		targetProject, err = manager.GetProject(d.Get("id").(string))
 		if err != nil {
-			return diag.Errorf("Error getting project: %s", err)
+			return apiErrorf("Error getting project: %s", err)
 		}
 	} else {
 		targetProject, err = GetProjectByName(d, manager)
 		if err != nil {
-			return diag.Errorf("Error getting project: %s", err)
+			return apiErrorf("Error getting project: %s", err)
 		}
 	}
 
@@ -47,7 +47,7 @@ func dataSourceRustackProjectRead(ctx context.Context, d *schema.ResourceData, m
 	}
 
 	if err := setResourceDataFromMap(d, flattenedProject); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 
 	d.SetId(targetProject.ID)
@@ -20,11 +20,11 @@ func dataSourceRustackProjects() *schema.Resource {
 }
 
 func dataSourceRustackProjectsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 
 	allProjects, err := manager.GetProjects()
 	if err != nil {
-		return diag.Errorf("Error getting projects: %s", err)
+		return apiErrorf("Error getting projects: %s", err)
 	}
 
 	flattenedRecords := make([]map[string]interface{}, len(allProjects))
@@ -37,13 +37,13 @@ func dataSourceRustackProjectsRead(ctx context.Context, d *schema.ResourceData,
 
 	hash, err := hashstructure.Hash(allProjects, hashstructure.FormatV2, nil)
 	if err != nil {
-		diag.Errorf("unable to set `projects` attribute: %s", err)
+		apiErrorf("unable to set `projects` attribute: %s", err)
 	}
 
 	d.SetId(fmt.Sprintf("projects/%d", hash))
 
 	if err := d.Set("projects", flattenedRecords); err != nil {
-		return diag.Errorf("unable to set `projects` attribute: %s", err)
+		return apiErrorf("unable to set `projects` attribute: %s", err)
 	}
 
 	return nil
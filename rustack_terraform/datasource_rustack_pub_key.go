@@ -21,34 +21,34 @@ func dataSourceRustackPublicKey() *schema.Resource {
 }
 
 func dataSourceRustackPublicKeyRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 
 	target, err := checkDatasourceNameOrId(d)
 	if err != nil {
-		return diag.Errorf("Error getting PublicKey: %s", err)
+		return apiErrorf("Error getting PublicKey: %s", err)
 	}
 	var targetPublicKey *rustack.PubKey
 	if target == "id" {
 		targetPublicKey, err = manager.GetPublicKey(d.Get("id").(string))
 		if err != nil {
-			return diag.Errorf("Error getting PublicKey: %s", err)
+			return apiErrorf("Error getting PublicKey: %s", err)
 		}
 	} else {
 		targetPublicKey, err = GetPubKeyByName(d, manager)
 		if err != nil {
-			return diag.Errorf("Error getting PublicKey: %s", err)
+			return apiErrorf("Error getting PublicKey: %s", err)
 		}
 	}
 
 	flatten := map[string]interface{}{
 		"id":          targetPublicKey.ID,
 		"name":        targetPublicKey.Name,
-		"public_key":  targetPublicKey.Fingerprint,
-		"fingerprint": targetPublicKey.PublicKey,
+		"public_key":  targetPublicKey.PublicKey,
+		"fingerprint": targetPublicKey.Fingerprint,
 	}
 
 	if err := setResourceDataFromMap(d, flatten); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 
 	d.SetId(targetPublicKey.ID)
@@ -21,21 +21,21 @@ func dataSourceRustackRouter() *schema.Resource {
 }
 
 func dataSourceRustackRouterRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	target, err := checkDatasourceNameOrId(d)
 	if err != nil {
-		return diag.Errorf("Error getting router: %s", err)
+		return apiErrorf("Error getting router: %s", err)
 	}
 	var router *rustack.Router
 	if target == "id" {
 		router, err = manager.GetRouter(d.Get("id").(string))
 		if err != nil {
-			return diag.Errorf("Error getting router: %s", err)
+			return apiErrorf("Error getting router: %s", err)
 		}
 	} else {
 		router, err = GetRouterByName(d, manager)
 		if err != nil {
-			return diag.Errorf("Error getting router: %s", err)
+			return apiErrorf("Error getting router: %s", err)
 		}
 	}
 
@@ -45,7 +45,7 @@ func dataSourceRustackRouterRead(ctx context.Context, d *schema.ResourceData, me
 	}
 
 	if err := setResourceDataFromMap(d, routerMap); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 
 	d.SetId(router.ID)
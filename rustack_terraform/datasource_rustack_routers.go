@@ -21,15 +21,15 @@ func dataSourceRustackRouters() *schema.Resource {
 }
 
 func dataSourceRustackRoutersRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	vdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Unable to get vdc: %s", err)
+		return apiErrorf("Unable to get vdc: %s", err)
 	}
 
 	allRouters, err := vdc.GetRouters()
 	if err != nil {
-		return diag.Errorf("Error getting routers: %s", err)
+		return apiErrorf("Error getting routers: %s", err)
 	}
 
 	routersMap := make([]map[string]interface{}, len(allRouters))
@@ -42,13 +42,13 @@ func dataSourceRustackRoutersRead(ctx context.Context, d *schema.ResourceData, m
 
 	hash, err := hashstructure.Hash(allRouters, hashstructure.FormatV2, nil)
 	if err != nil {
-		return diag.Errorf("unable to set `routers` attribute: %s", err)
+		return apiErrorf("unable to set `routers` attribute: %s", err)
 	}
 
 	d.SetId(fmt.Sprintf("routers/%d", hash))
 
 	if err := d.Set("routers", routersMap); err != nil {
-		return diag.Errorf("unable to set `routers` attribute: %s", err)
+		return apiErrorf("unable to set `routers` attribute: %s", err)
 	}
 
 	return nil
@@ -21,22 +21,22 @@ func dataSourceRustackS3Storage() *schema.Resource {
 }
 
 func dataSourceRustackS3StorageRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 
 	target, err := checkDatasourceNameOrId(d)
 	if err != nil {
-		return diag.Errorf("Error getting s3 storage: %s", err)
+		return apiErrorf("Error getting s3 storage: %s", err)
 	}
 	var s3_storage *rustack.S3Storage
 	if target == "id" {
 		s3_storage, err = manager.GetS3Storage(d.Get("id").(string))
 		if err != nil {
-			return diag.Errorf("Error getting storage: %s", err)
+			return apiErrorf("Error getting storage: %s", err)
 		}
 	} else {
 		s3_storage, err = GetS3ByName(d, manager)
 		if err != nil {
-			return diag.Errorf("Error getting storage: %s", err)
+			return apiErrorf("Error getting storage: %s", err)
 		}
 	}
 
@@ -50,7 +50,7 @@ func dataSourceRustackS3StorageRead(ctx context.Context, d *schema.ResourceData,
 	}
 
 	if err := setResourceDataFromMap(d, flatten); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 
 	d.SetId(s3_storage.ID)
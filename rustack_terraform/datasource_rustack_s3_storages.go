@@ -21,15 +21,15 @@ func dataSourceRustackS3Storages() *schema.Resource {
 }
 
 func dataSourceRustackS3Read(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	project, err := GetProjectById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting project: %s", err)
+		return apiErrorf("Error getting project: %s", err)
 	}
 
 	s3_storages, err := project.GetS3Storages()
 	if err != nil {
-		return diag.Errorf("Error retrieving storages: %s", err)
+		return apiErrorf("Error retrieving storages: %s", err)
 	}
 
 	flattenedRecords := make([]map[string]interface{}, len(s3_storages))
@@ -47,13 +47,13 @@ func dataSourceRustackS3Read(ctx context.Context, d *schema.ResourceData, meta i
 
 	hash, err := hashstructure.Hash(s3_storages, hashstructure.FormatV2, nil)
 	if err != nil {
-		diag.Errorf("unable to set `s3_storages` attribute: %s", err)
+		apiErrorf("unable to set `s3_storages` attribute: %s", err)
 	}
 
 	d.SetId(fmt.Sprintf("s3_storages/%d", hash))
 
 	if err := d.Set("s3_storages", flattenedRecords); err != nil {
-		return diag.Errorf("unable to set `s3_storages` attribute: %s", err)
+		return apiErrorf("unable to set `s3_storages` attribute: %s", err)
 	}
 
 	return nil
@@ -21,26 +21,26 @@ func dataSourceRustackStorageProfile() *schema.Resource {
 }
 
 func dataSourceRustackStorageProfileRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	target, err := checkDatasourceNameOrId(d)
 	if err != nil {
-		return diag.Errorf("Error getting storage profile: %s", err)
+		return apiErrorf("Error getting storage profile: %s", err)
 	}
 	var targetStorageProfile *rustack.StorageProfile
 	if target == "id" {
 		targetStorageProfile, err = targetVdc.GetStorageProfile(d.Get("id").(string))
 		if err != nil {
-			return diag.Errorf("Error getting storage profile: %s", err)
+			return apiErrorf("Error getting storage profile: %s", err)
 		}
 	} else {
 		targetStorageProfile, err = GetStorageProfileByName(d, manager, targetVdc)
 		if err != nil {
-			return diag.Errorf("Error getting storage profile: %s", err)
+			return apiErrorf("Error getting storage profile: %s", err)
 		}
 	}
 
@@ -50,7 +50,7 @@ func dataSourceRustackStorageProfileRead(ctx context.Context, d *schema.Resource
 	}
 
 	if err := setResourceDataFromMap(d, flatten); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 
 	d.SetId(targetStorageProfile.ID)
@@ -21,10 +21,10 @@ func dataSourceRustackStorageProfiles() *schema.Resource {
 }
 
 func dataSourceRustackStorageProfilesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting VDC: %s", err)
+		return apiErrorf("Error getting VDC: %s", err)
 	}
 
 	storageProfiles, err := targetVdc.GetStorageProfiles()
@@ -42,13 +42,13 @@ func dataSourceRustackStorageProfilesRead(ctx context.Context, d *schema.Resourc
 
 	hash, err := hashstructure.Hash(storageProfiles, hashstructure.FormatV2, nil)
 	if err != nil {
-		diag.Errorf("unable to set `storage_profiles` attribute: %s", err)
+		apiErrorf("unable to set `storage_profiles` attribute: %s", err)
 	}
 
 	d.SetId(fmt.Sprintf("storage_profiles/%d", hash))
 
 	if err := d.Set("storage_profiles", flattenedStorageProfiles); err != nil {
-		return diag.Errorf("unable to set `storage_profiles` attribute: %s", err)
+		return apiErrorf("unable to set `storage_profiles` attribute: %s", err)
 	}
 
 	return nil
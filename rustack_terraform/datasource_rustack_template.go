@@ -21,26 +21,26 @@ func dataSourceRustackTemplate() *schema.Resource {
 }
 
 func dataSourceRustackTemplateRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	target, err := checkDatasourceNameOrId(d)
 	if err != nil {
-		return diag.Errorf("Error getting template: %s", err)
+		return apiErrorf("Error getting template: %s", err)
 	}
 	var targetTemplate *rustack.Template
 	if target == "id" {
 		targetTemplate, err = manager.GetTemplate(d.Get("id").(string))
 		if err != nil {
-			return diag.Errorf("Error getting template: %s", err)
+			return apiErrorf("Error getting template: %s", err)
 		}
 	} else {
 		targetTemplate, err = GetTemplateByName(d, manager, targetVdc)
 		if err != nil {
-			return diag.Errorf("Error getting template: %s", err)
+			return apiErrorf("Error getting template: %s", err)
 		}
 	}
 
@@ -53,7 +53,7 @@ func dataSourceRustackTemplateRead(ctx context.Context, d *schema.ResourceData,
 	}
 
 	if err := setResourceDataFromMap(d, flatten); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 
 	d.SetId(targetTemplate.ID)
@@ -21,15 +21,15 @@ func dataSourceRustackTemplates() *schema.Resource {
 }
 
 func dataSourceRustackTemplatesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	allTemplates, err := targetVdc.GetTemplates()
 	if err != nil {
-		return diag.Errorf("Error retrieving templates: %s", err)
+		return apiErrorf("Error retrieving templates: %s", err)
 	}
 
 	flattenedRecords := make([]map[string]interface{}, len(allTemplates))
@@ -45,13 +45,13 @@ func dataSourceRustackTemplatesRead(ctx context.Context, d *schema.ResourceData,
 
 	hash, err := hashstructure.Hash(allTemplates, hashstructure.FormatV2, nil)
 	if err != nil {
-		diag.Errorf("unable to set `templates` attribute: %s", err)
+		apiErrorf("unable to set `templates` attribute: %s", err)
 	}
 
 	d.SetId(fmt.Sprintf("templates/%d", hash))
 
 	if err := d.Set("templates", flattenedRecords); err != nil {
-		return diag.Errorf("unable to set `templates` attribute: %s", err)
+		return apiErrorf("unable to set `templates` attribute: %s", err)
 	}
 
 	return nil
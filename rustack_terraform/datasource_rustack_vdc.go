@@ -21,14 +21,14 @@ func dataSourceRustackVdc() *schema.Resource {
 }
 
 func dataSourceRustackVdcRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 
 	var targetProject *rustack.Project
 
 	if _, exists := d.GetOk("project_id"); exists {
 		project, err := GetProjectById(d, manager)
 		if err != nil {
-			return diag.Errorf("Error getting project: %s", err)
+			return apiErrorf("Error getting project: %s", err)
 		}
 
 		targetProject = project
@@ -36,18 +36,18 @@ func dataSourceRustackVdcRead(ctx context.Context, d *schema.ResourceData, meta
 
 	target, err := checkDatasourceNameOrId(d)
 	if err != nil {
-		return diag.Errorf("Error getting VDC: %s", err)
+		return apiErrorf("Error getting VDC: %s", err)
 	}
 	var targetVdc *rustack.Vdc
 	if target == "id" {
 		targetVdc, err = manager.GetVdc(d.Get("id").(string))
 		if err != nil {
-			return diag.Errorf("Error getting VDC: %s", err)
+			return apiErrorf("Error getting VDC: %s", err)
 		}
 	} else {
 		targetVdc, err = GetVdcByName(d, manager, targetProject)
 		if err != nil {
-			return diag.Errorf("Error getting VDC: %s", err)
+			return apiErrorf("Error getting VDC: %s", err)
 		}
 	}
 
@@ -59,7 +59,7 @@ func dataSourceRustackVdcRead(ctx context.Context, d *schema.ResourceData, meta
 	}
 
 	if err := setResourceDataFromMap(d, flattenedVdc); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 
 	d.SetId(targetVdc.ID)
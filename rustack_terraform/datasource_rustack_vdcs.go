@@ -22,15 +22,15 @@ func dataSourceRustackVdcs() *schema.Resource {
 }
 
 func dataSourceRustackVdcsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetProject, err := GetProjectById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting project: %s", err)
+		return apiErrorf("Error getting project: %s", err)
 	}
 
 	allVdcs, err := manager.GetVdcs(rustack.Arguments{"project": targetProject.ID})
 	if err != nil {
-		return diag.Errorf("Error retrieving vdcs: %s", err)
+		return apiErrorf("Error retrieving vdcs: %s", err)
 	}
 
 	flattenedRecords := make([]map[string]interface{}, len(allVdcs))
@@ -46,13 +46,13 @@ func dataSourceRustackVdcsRead(ctx context.Context, d *schema.ResourceData, meta
 
 	hash, err := hashstructure.Hash(allVdcs, hashstructure.FormatV2, nil)
 	if err != nil {
-		diag.Errorf("unable to set `vdcs` attribute: %s", err)
+		apiErrorf("unable to set `vdcs` attribute: %s", err)
 	}
 
 	d.SetId(fmt.Sprintf("vdcs/%d", hash))
 
 	if err := d.Set("vdcs", flattenedRecords); err != nil {
-		return diag.Errorf("unable to set `vdcs` attribute: %s", err)
+		return apiErrorf("unable to set `vdcs` attribute: %s", err)
 	}
 
 	return nil
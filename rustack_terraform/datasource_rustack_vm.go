@@ -21,25 +21,25 @@ func dataSourceRustackVm() *schema.Resource {
 }
 
 func dataSourceRustackVmRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 	target, err := checkDatasourceNameOrId(d)
 	if err != nil {
-		return diag.Errorf("Error getting vm: %s", err)
+		return apiErrorf("Error getting vm: %s", err)
 	}
 	var targetVm *rustack.Vm
 	if target == "id" {
 		targetVm, err = manager.GetVm(d.Get("id").(string))
 		if err != nil {
-			return diag.Errorf("Error getting vm: %s", err)
+			return apiErrorf("Error getting vm: %s", err)
 		}
 	} else {
 		targetVm, err = GetVmByName(d, manager, targetVdc)
 		if err != nil {
-			return diag.Errorf("Error getting vm: %s", err)
+			return apiErrorf("Error getting vm: %s", err)
 		}
 	}
 	flattenPorts := make([]map[string]interface{}, 0, len(targetVm.Ports))
@@ -69,7 +69,7 @@ func dataSourceRustackVmRead(ctx context.Context, d *schema.ResourceData, meta i
 	}
 
 	if err := setResourceDataFromMap(d, flatten); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 
 	d.SetId(targetVm.ID)
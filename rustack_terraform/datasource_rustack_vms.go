@@ -21,15 +21,15 @@ func dataSourceRustackVms() *schema.Resource {
 }
 
 func dataSourceRustackVmsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("Error getting vdc: %s", err)
+		return apiErrorf("Error getting vdc: %s", err)
 	}
 
 	allVms, err := targetVdc.GetVms()
 	if err != nil {
-		return diag.Errorf("Error retrieving vms: %s", err)
+		return apiErrorf("Error retrieving vms: %s", err)
 	}
 
 	flattenedRecords := make([]map[string]interface{}, len(allVms))
@@ -54,13 +54,13 @@ func dataSourceRustackVmsRead(ctx context.Context, d *schema.ResourceData, meta
 
 	hash, err := hashstructure.Hash(allVms, hashstructure.FormatV2, nil)
 	if err != nil {
-		diag.Errorf("unable to set `vms` attribute: %s", err)
+		apiErrorf("unable to set `vms` attribute: %s", err)
 	}
 
 	d.SetId(fmt.Sprintf("vms/%d", hash))
 
 	if err := d.Set("vms", flattenedRecords); err != nil {
-		return diag.Errorf("unable to set `vms` attribute: %s", err)
+		return apiErrorf("unable to set `vms` attribute: %s", err)
 	}
 
 	return nil
@@ -1,10 +1,14 @@
 package rustack_terraform
 
 import (
+	"regexp"
+
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
+var scsiAddressRegexp = regexp.MustCompile(`^[0-9]+:[0-9]+$`)
+
 func (args *Arguments) injectContextGetDisk() {
 	args.merge(Arguments{
 		"name": {
@@ -54,7 +58,8 @@ func (args *Arguments) injectCreateDisk() {
 			Computed:    true,
 			Description: "external id of the volume. It can be empty",
 		},
-		"tags": newTagNamesResourceSchema("tags of the Vm"),
+		"tags":     newTagNamesResourceSchema("tags of the Vm"),
+		"tags_all": newTagsAllResourceSchema("tags of the Disk including the provider default tags"),
 	})
 }
 
@@ -107,3 +112,35 @@ func (args *Arguments) injectResultListDisk() {
 		},
 	})
 }
+
+func (args *Arguments) injectCreateDiskAttachment() {
+	args.merge(Arguments{
+		"disk_id": {
+			Type:        schema.TypeString,
+			Required:    true,
+			ForceNew:    true,
+			Description: "id of the Disk to attach",
+		},
+		"vm_id": {
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: "id of the Vm the Disk is attached to. Changing it moves the Disk to another Vm",
+		},
+		"force_detach": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "detach the Disk from another Vm it is attached to instead of failing",
+		},
+		"scsi": {
+			Type:     schema.TypeString,
+			Optional: true,
+			Computed: true,
+			ForceNew: true,
+			ValidateFunc: validation.StringMatch(
+				scsiAddressRegexp, "expected a bus and slot, e.g. 0:1",
+			),
+			Description: "bus and slot of the Disk on the Vm, e.g. 0:1. Assigned by the platform unless set",
+		},
+	})
+}
@@ -50,9 +50,10 @@ func (args *Arguments) injectCreateDns() {
 		},
 		"project_id": {
 			Type:        schema.TypeString,
-			Required:    true,
+			Optional:    true,
+			Computed:    true,
 			ForceNew:    true,
-			Description: "id of the Project",
+			Description: "id of the Project. Defaults to the provider default project",
 		},
 		"tags": {
 			Type:     schema.TypeSet,
@@ -0,0 +1,130 @@
+package rustack_terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/pkg/errors"
+	"github.com/rustack-cloud-platform/rcp-go/rustack"
+)
+
+// errorClass groups API failures by what the user can do about them.
+type errorClass int
+
+const (
+	errorUnknown errorClass = iota
+	errorNotFound
+	errorConflict
+	errorUnauthorized
+	errorQuotaExceeded
+	errorTransient
+	errorProtocol
+)
+
+// classifyError finds out why an API call failed. It accepts any error,
+// wrapped or not, and never panics: errors that did not come from the API
+// are transient network failures, answers the client could not decode, or
+// unknown.
+func classifyError(err error) errorClass {
+	if err == nil {
+		return errorUnknown
+	}
+
+	var apiErr *rustack.RustackApiError
+	if errors.As(err, &apiErr) {
+		for _, alias := range apiErr.ErrorAliases() {
+			if strings.Contains(alias, "quota") || strings.Contains(alias, "limit_exceeded") {
+				return errorQuotaExceeded
+			}
+		}
+
+		switch code := apiErr.Code(); {
+		case code == 404:
+			return errorNotFound
+		case code == 401 || code == 403:
+			return errorUnauthorized
+		case code == 409 || code == 423:
+			return errorConflict
+		case code == 429 || code >= 500:
+			return errorTransient
+		}
+		return errorUnknown
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return errorTransient
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return errorTransient
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return errorProtocol
+	}
+
+	return errorUnknown
+}
+
+func isNotFound(err error) bool {
+	return classifyError(err) == errorNotFound
+}
+
+func isTransient(err error) bool {
+	return classifyError(err) == errorTransient
+}
+
+func (c errorClass) hint() string {
+	switch c {
+	case errorNotFound:
+		return "The object does not exist or is not visible with the configured token."
+	case errorConflict:
+		return "The object is locked by a running task or conflicts with another object. " +
+			"Wait for the task to finish or increase the resource timeouts."
+	case errorUnauthorized:
+		return "The API rejected the request. Check the provider token and its access to the project."
+	case errorQuotaExceeded:
+		return "A quota of the client or project is exhausted. Free some resources or ask for a larger quota."
+	case errorTransient:
+		return "The API or the network failed temporarily. Running the command again usually helps; " +
+			"the provider retry block controls automatic retries."
+	case errorProtocol:
+		return "The API answered with something other than JSON. Check that api_endpoint points to the Rustack API."
+	}
+	return ""
+}
+
+// apiErrorf works like diag.Errorf and adds a hint about the failure when
+// one of the arguments is an error of a known class.
+func apiErrorf(format string, args ...interface{}) diag.Diagnostics {
+	diagnostic := diag.Diagnostic{
+		Severity: diag.Error,
+		Summary:  fmt.Sprintf(format, args...),
+	}
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			diagnostic.Detail = classifyError(err).hint()
+			break
+		}
+	}
+	return diag.Diagnostics{diagnostic}
+}
+
+// apiErrorDiag works like diag.FromErr and adds a hint about the failure
+// when the error is of a known class.
+func apiErrorDiag(err error) diag.Diagnostics {
+	if err == nil {
+		return nil
+	}
+	return diag.Diagnostics{diag.Diagnostic{
+		Severity: diag.Error,
+		Summary:  err.Error(),
+		Detail:   classifyError(err).hint(),
+	}}
+}
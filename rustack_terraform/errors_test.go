@@ -0,0 +1,132 @@
+package rustack_terraform
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pkg/errors"
+	"github.com/rustack-cloud-platform/rcp-go/rustack"
+)
+
+func testApiError(status int, body string) error {
+	return rustack.NewRustackApiError("https://api.example.com/v1/vm", &http.Response{
+		StatusCode: status,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	})
+}
+
+func TestClassifyError(t *testing.T) {
+	var syntaxErr error = &json.SyntaxError{}
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	_, netErr := http.Get(closed.URL)
+
+	cases := map[string]struct {
+		err      error
+		expected errorClass
+	}{
+		"nil":              {nil, errorUnknown},
+		"plain":            {errors.New("something"), errorUnknown},
+		"not found":        {testApiError(404, `{}`), errorNotFound},
+		"wrapped":          {errors.Wrap(testApiError(404, `{}`), "Error getting vm"), errorNotFound},
+		"unauthorized":     {testApiError(401, `{}`), errorUnauthorized},
+		"forbidden":        {testApiError(403, `{}`), errorUnauthorized},
+		"locked":           {testApiError(409, `{"error_alias":["object_locked"]}`), errorConflict},
+		"quota":            {testApiError(400, `{"error_alias":["quota_exceeded"]}`), errorQuotaExceeded},
+		"too many":         {testApiError(429, `{}`), errorTransient},
+		"unavailable":      {testApiError(503, `{}`), errorTransient},
+		"validation":       {testApiError(400, `{"name":["required"]}`), errorUnknown},
+		"network":          {errors.Wrap(netErr, "HTTP request failure"), errorTransient},
+		"deadline":         {context.DeadlineExceeded, errorTransient},
+		"unexpected eof":   {io.ErrUnexpectedEOF, errorTransient},
+		"malformed answer": {errors.Wrap(syntaxErr, "JSON decode failed"), errorProtocol},
+	}
+
+	for name, c := range cases {
+		if got := classifyError(c.err); got != c.expected {
+			t.Errorf("%s: expected class %d, got %d", name, c.expected, got)
+		}
+	}
+}
+
+func TestApiErrorf(t *testing.T) {
+	diags := apiErrorf("id: Error getting vm: %s", testApiError(404, `{}`))
+	if len(diags) != 1 || !diags.HasError() {
+		t.Fatalf("expected a single error diagnostic, got %#v", diags)
+	}
+	if !strings.HasPrefix(diags[0].Summary, "id: Error getting vm: HTTP request failure") {
+		t.Errorf("unexpected summary %q", diags[0].Summary)
+	}
+	if diags[0].Detail != errorNotFound.hint() {
+		t.Errorf("unexpected detail %q", diags[0].Detail)
+	}
+
+	if diags := apiErrorf("name: must be ending by '.'"); diags[0].Detail != "" {
+		t.Errorf("expected no detail without an error, got %q", diags[0].Detail)
+	}
+}
+
+// testReadAll calls the Read function of every resource for an object that
+// does not exist, with all of its references pointing to missing objects too.
+func testReadAll(t *testing.T, manager *rustack.Manager, check func(name string, d *schema.ResourceData, err error)) {
+	const missingID = "00000000-0000-4000-8000-999999999999"
+	for name, r := range Provider().ResourcesMap {
+		d := r.TestResourceData()
+		d.SetId(missingID)
+		for key, s := range r.Schema {
+			if s.Type == schema.TypeString && strings.HasSuffix(key, "_id") {
+				d.Set(key, missingID)
+			}
+		}
+
+		func() {
+			defer func() {
+				if p := recover(); p != nil {
+					t.Errorf("%s: Read panicked: %v", name, p)
+				}
+			}()
+
+			diags := r.ReadContext(context.Background(), d, &CombinedConfig{manager: manager})
+			var err error
+			if diags.HasError() {
+				err = errors.New(diags[0].Summary)
+			}
+			check(name, d, err)
+		}()
+	}
+}
+
+func TestResourceRead_notFound(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	testReadAll(t, api.manager(), func(name string, d *schema.ResourceData, err error) {
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", name, err)
+		}
+		if d.Id() != "" {
+			t.Errorf("%s: a missing object has to be removed from the state", name)
+		}
+	})
+}
+
+func TestResourceRead_unreachable(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	manager := rustack.NewManager("fake-token")
+	manager.BaseURL = closed.URL
+
+	testReadAll(t, manager, func(name string, d *schema.ResourceData, err error) {
+		if err == nil {
+			t.Errorf("%s: expected an error for an unreachable API", name)
+		}
+		if d.Id() == "" {
+			t.Errorf("%s: an object must not be removed from the state on a network error", name)
+		}
+	})
+}
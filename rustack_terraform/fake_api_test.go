@@ -0,0 +1,993 @@
+package rustack_terraform
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rustack-cloud-platform/rcp-go/rustack"
+)
+
+// fakeRustackAPI is an in-process stand-in for the Rustack HTTP API. It keeps
+// every object in memory and answers with the same JSON shapes rcp-go
+// expects, so the provider can be exercised end to end without a network.
+type fakeRustackAPI struct {
+	t      *testing.T
+	server *httptest.Server
+	token  string
+
+	mu      sync.Mutex
+	seq     int
+	ipSeq   int
+	objects map[string]map[string]fakeObject
+	locks   map[string]int
+
+	failures   int
+	failStatus int
+
+	AccountID        string
+	ClientID         string
+	HypervisorID     string
+	StorageProfileID string
+	TemplateID       string
+	FirewallID       string
+	K8sTemplateID    string
+	PlatformID       string
+	PubKeyID         string
+}
+
+type fakeObject map[string]interface{}
+
+// fakeRaw is a response body that is sent as is instead of as JSON.
+type fakeRaw string
+
+// collections whose list endpoint is read by rcp-go with a plain Get and
+// therefore answers with a bare JSON array instead of a paginated envelope.
+var fakeRawListCollections = map[string]bool{
+	"template": true,
+	"platform": true,
+	"rule":     true,
+	"pool":     true,
+}
+
+func newFakeRustackAPI(t *testing.T) *fakeRustackAPI {
+	api := &fakeRustackAPI{
+		t:       t,
+		token:   "fake-token",
+		objects: make(map[string]map[string]fakeObject),
+		locks:   make(map[string]int),
+	}
+
+	api.AccountID = api.put("account", fakeObject{"email": "terraform@example.com", "username": "terraform"})
+	api.HypervisorID = api.put("hypervisor", fakeObject{"name": "Ресурсный пул KVM", "type": "kvm"})
+	api.put("hypervisor", fakeObject{"name": "Ресурсный пул VMware", "type": "vmware"})
+	api.ClientID = api.put("client", fakeObject{"name": "Terraform client", "payment_model": "prepay"})
+	api.StorageProfileID = api.put("storage_profile", fakeObject{"name": "ssd"})
+	api.put("storage_profile", fakeObject{"name": "sas"})
+	api.TemplateID = api.put("template", fakeObject{"name": "Debian 10", "min_cpu": 1, "min_ram": 1, "min_hdd": 5})
+	api.FirewallID = api.put("firewall", fakeObject{"name": "По-умолчанию", "tags": []string{}})
+	api.K8sTemplateID = api.put("kubernetes_template", fakeObject{"name": "Kubernetes 1.22.1", "min_node_cpu": 2, "min_node_ram": 2, "min_node_hdd": 20})
+	api.PlatformID = api.put("platform", fakeObject{"name": "Intel Cascade Lake", "hypervisor": api.HypervisorID})
+	api.PubKeyID = api.put("key", fakeObject{"account": api.AccountID, "name": "terraform", "fingerprint": "aa:bb:cc", "public_key": "ssh-ed25519 AAAA terraform"})
+
+	api.server = httptest.NewServer(http.HandlerFunc(api.serveHTTP))
+	t.Cleanup(api.server.Close)
+
+	return api
+}
+
+// providerConfig returns a provider block pointing at the fake API.
+func (api *fakeRustackAPI) providerConfig() string {
+	return fmt.Sprintf(`
+provider "rustack" {
+  api_endpoint = %q
+  token        = %q
+}
+`, api.server.URL, api.token)
+}
+
+// manager returns an API client talking to the fake API, for tests that call
+// provider helpers directly.
+func (api *fakeRustackAPI) manager() *rustack.Manager {
+	manager := rustack.NewManager(api.token)
+	manager.BaseURL = api.server.URL
+	return manager
+}
+
+func (api *fakeRustackAPI) newID() string {
+	api.seq++
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", api.seq)
+}
+
+func (api *fakeRustackAPI) put(collection string, obj fakeObject) string {
+	if _, ok := obj["id"]; !ok {
+		obj["id"] = api.newID()
+	}
+	obj["_seq"] = api.seq
+	if api.objects[collection] == nil {
+		api.objects[collection] = make(map[string]fakeObject)
+	}
+	id := obj["id"].(string)
+	api.objects[collection][id] = obj
+	return id
+}
+
+func (api *fakeRustackAPI) get(collection, id string) fakeObject {
+	return api.objects[collection][id]
+}
+
+func (api *fakeRustackAPI) list(collection string, match func(fakeObject) bool) []fakeObject {
+	result := make([]fakeObject, 0)
+	for _, obj := range api.objects[collection] {
+		if match == nil || match(obj) {
+			result = append(result, obj)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i]["_seq"].(int) < result[j]["_seq"].(int)
+	})
+	return result
+}
+
+// Exists reports whether an object is still present in the fake API.
+func (api *fakeRustackAPI) Exists(collection, id string) bool {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.get(collection, id) != nil
+}
+
+// Find returns the id of the first object in a collection with the given name.
+func (api *fakeRustackAPI) Find(collection, name string) string {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	for _, obj := range api.list(collection, nil) {
+		if obj["name"] == name {
+			return obj["id"].(string)
+		}
+	}
+	return ""
+}
+
+// Object returns a rendered copy of an object as the API would return it.
+func (api *fakeRustackAPI) Object(collection, id string) map[string]interface{} {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	obj := api.get(collection, id)
+	if obj == nil {
+		return nil
+	}
+	return api.render(collection, obj)
+}
+
+// Update changes an object behind the provider's back, like a user would do
+// in the web console.
+func (api *fakeRustackAPI) Update(collection, id string, values map[string]interface{}) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	obj := api.get(collection, id)
+	if obj == nil {
+		api.t.Fatalf("fake api: %s %s not found", collection, id)
+	}
+	for key, value := range values {
+		obj[key] = value
+	}
+}
+
+// Lock keeps an object locked for the given number of requests touching it.
+// Reads report "locked": true and mutations are rejected with 409
+// object_locked, the same way the API behaves while a task is running.
+func (api *fakeRustackAPI) Lock(id string, requests int) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.locks[id] = requests
+}
+
+// Fail answers the next mutating requests with the given status code, the way
+// an overloaded or restarting API does.
+func (api *fakeRustackAPI) Fail(status, requests int) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.failStatus = status
+	api.failures = requests
+}
+
+// CreateVdc adds a project with a vdc behind the provider's back, for tests
+// that need them before the provider is configured.
+func (api *fakeRustackAPI) CreateVdc(projectName, vdcName string) (projectID, vdcID string) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	projectID = api.put("project", fakeObject{"name": projectName, "client": api.ClientID, "tags": []string{}})
+	vdc := fakeObject{"name": vdcName, "project": projectID, "hypervisor": api.HypervisorID, "tags": []string{}}
+	vdcID = api.put("vdc", vdc)
+	api.createVdcDefaults(vdc)
+	return projectID, vdcID
+}
+
+func (api *fakeRustackAPI) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer "+api.token {
+		api.writeError(w, http.StatusUnauthorized, "Invalid token")
+		return
+	}
+
+	if api.failures > 0 && r.Method != http.MethodGet {
+		api.failures--
+		api.writeError(w, api.failStatus, http.StatusText(api.failStatus))
+		return
+	}
+
+	var body map[string]interface{}
+	if r.Body != nil {
+		raw, _ := io.ReadAll(r.Body)
+		if len(raw) > 0 {
+			json.Unmarshal(raw, &body)
+		}
+	}
+	if body == nil {
+		body = map[string]interface{}{}
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1"), "/")
+	segments := strings.Split(path, "/")
+
+	locked := false
+	if len(segments) >= 2 && api.locks[segments[1]] > 0 {
+		api.locks[segments[1]]--
+		if r.Method != http.MethodGet {
+			api.writeErrorAlias(w, http.StatusConflict, "Object is locked", "object_locked")
+			return
+		}
+		locked = true
+	}
+
+	status, result := api.route(r.Method, segments, r.URL.Query(), body)
+	if obj, ok := result.(map[string]interface{}); ok && locked {
+		obj["locked"] = true
+	}
+	if raw, ok := result.(fakeRaw); ok {
+		w.WriteHeader(status)
+		w.Write([]byte(raw))
+		return
+	}
+	switch {
+	case status >= 400:
+		api.writeError(w, status, fmt.Sprint(result))
+	case result == nil:
+		w.WriteHeader(status)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+func (api *fakeRustackAPI) writeError(w http.ResponseWriter, status int, message string) {
+	api.writeErrorAlias(w, status, message, strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_")))
+}
+
+func (api *fakeRustackAPI) writeErrorAlias(w http.ResponseWriter, status int, message, alias string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"non_field_errors": []string{message},
+		"error_alias":      []string{alias},
+	})
+}
+
+type fakeQuery interface {
+	Get(string) string
+}
+
+func (api *fakeRustackAPI) route(method string, segments []string, query fakeQuery, body map[string]interface{}) (int, interface{}) {
+	collection := segments[0]
+
+	switch {
+	case collection == "account" && len(segments) == 2 && segments[1] == "me":
+		return http.StatusOK, api.render("account", api.get("account", api.AccountID))
+	case collection == "account" && len(segments) >= 3 && segments[2] == "key":
+		return api.routeCollection(method, "key", segments[3:], query, body, fakeObject{"account": segments[1]})
+	case collection == "job":
+		return http.StatusOK, map[string]interface{}{"status": "done", "name": "fake"}
+	}
+
+	if len(segments) >= 3 {
+		parent := api.get(collection, segments[1])
+		if parent == nil {
+			return http.StatusNotFound, "Not found"
+		}
+		return api.routeAction(method, collection, parent, segments[2], segments[3:], query, body)
+	}
+
+	return api.routeCollection(method, collection, segments[1:], query, body, nil)
+}
+
+func (api *fakeRustackAPI) routeCollection(method, collection string, rest []string, query fakeQuery, body map[string]interface{}, scope fakeObject) (int, interface{}) {
+	if len(rest) == 0 || rest[0] == "" {
+		switch method {
+		case http.MethodGet:
+			items := api.list(collection, func(obj fakeObject) bool {
+				for key, value := range scope {
+					if obj[key] != value {
+						return false
+					}
+				}
+				return fakeMatchQuery(obj, query)
+			})
+			rendered := make([]interface{}, len(items))
+			for i, item := range items {
+				rendered[i] = api.render(collection, item)
+			}
+			if fakeRawListCollections[collection] {
+				return http.StatusOK, rendered
+			}
+			limit := len(rendered)
+			if limit == 0 {
+				limit = 1
+			}
+			return http.StatusOK, map[string]interface{}{"total": len(rendered), "limit": limit, "items": rendered}
+		case http.MethodPost:
+			for key, value := range scope {
+				body[key] = value
+			}
+			obj, status, msg := api.create(collection, body)
+			if status >= 400 {
+				return status, msg
+			}
+			return http.StatusCreated, api.render(collection, obj)
+		}
+		return http.StatusMethodNotAllowed, "Method not allowed"
+	}
+
+	obj := api.get(collection, rest[0])
+	if obj == nil {
+		return http.StatusNotFound, "Not found"
+	}
+	switch method {
+	case http.MethodGet:
+		return http.StatusOK, api.render(collection, obj)
+	case http.MethodPut:
+		if status, msg := api.update(collection, obj, body); status >= 400 {
+			return status, msg
+		}
+		return http.StatusOK, api.render(collection, obj)
+	case http.MethodDelete:
+		api.delete(collection, obj)
+		return http.StatusNoContent, nil
+	}
+	return http.StatusMethodNotAllowed, "Method not allowed"
+}
+
+func (api *fakeRustackAPI) routeAction(method, collection string, parent fakeObject, action string, rest []string, query fakeQuery, body map[string]interface{}) (int, interface{}) {
+	id := parent["id"].(string)
+
+	switch collection + "/" + action {
+	case "network/subnet":
+		return api.routeCollection(method, "subnet", rest, query, body, fakeObject{"network": id})
+	case "firewall/rule":
+		return api.routeCollection(method, "rule", rest, query, body, fakeObject{"firewall": id})
+	case "dns/record", "dns/dns_record":
+		return api.routeCollection(method, "record", rest, query, body, fakeObject{"dns": id})
+	case "lbaas/pool":
+		return api.routeCollection(method, "pool", rest, query, body, fakeObject{"lbaas": id})
+	case "s3_storage/bucket":
+		return api.routeCollection(method, "bucket", rest, query, body, fakeObject{"s3_storage": id})
+	case "port/disconnect":
+		parent["connected"] = nil
+		return http.StatusOK, api.render("port", parent)
+	case "port/force":
+		api.delete("port", parent)
+		return http.StatusNoContent, nil
+	case "vm/state":
+		// state changes are asynchronous, the API only acknowledges them
+		parent["power"] = body["state"] != "power_off"
+		return http.StatusAccepted, nil
+	case "disk/attach":
+		vm := api.get("vm", fakeString(body["vm"]))
+		if vm == nil {
+			return http.StatusBadRequest, "Unknown vm"
+		}
+		if current := fakeString(parent["vm"]); current != "" && current != vm["id"] {
+			return http.StatusBadRequest, "Disk is attached to another vm"
+		}
+		parent["vm"] = vm["id"]
+		parent["_scsi"] = "0:1"
+		if scsi := fakeString(body["scsi"]); scsi != "" {
+			parent["_scsi"] = scsi
+		}
+		// the API lists disks of a vm in attachment order, system disk first
+		api.seq++
+		parent["_seq"] = api.seq
+		return http.StatusOK, api.render("disk", parent)
+	case "disk/detach":
+		if parent["_system"] == true {
+			return http.StatusBadRequest, "System disk can not be detached"
+		}
+		parent["vm"] = nil
+		return http.StatusOK, api.render("disk", parent)
+	case "kubernetes/dashboard":
+		return http.StatusOK, map[string]interface{}{"url": fmt.Sprintf("/kubernetes/%s/dashboard", id)}
+	case "kubernetes/config":
+		return http.StatusOK, fakeKubeconfig(id)
+	}
+
+	return http.StatusNotFound, "Not found"
+}
+
+func fakeMatchQuery(obj fakeObject, query fakeQuery) bool {
+	for _, key := range []string{"vdc", "project", "name"} {
+		value := query.Get(key)
+		if value == "" {
+			continue
+		}
+		if current, ok := obj[key]; ok && current != nil && current != "" && current != value {
+			return false
+		}
+	}
+	if query.Get("defaults_only") == "true" && obj["is_default"] != true {
+		return false
+	}
+	return true
+}
+
+func (api *fakeRustackAPI) create(collection string, body map[string]interface{}) (fakeObject, int, string) {
+	obj := fakeObject{}
+	for key, value := range body {
+		obj[key] = value
+	}
+	delete(obj, "id")
+	delete(obj, "locked")
+	if _, ok := obj["tags"]; ok || collection != "subnet" {
+		obj["tags"] = fakeStrings(obj["tags"])
+	}
+
+	switch collection {
+	case "project":
+		if api.get("client", fakeString(body["client"])) == nil {
+			return nil, http.StatusBadRequest, "Unknown client"
+		}
+	case "vdc":
+		if api.get("project", fakeString(body["project"])) == nil {
+			return nil, http.StatusBadRequest, "Unknown project"
+		}
+		if api.get("hypervisor", fakeString(body["hypervisor"])) == nil {
+			return nil, http.StatusBadRequest, "Unknown hypervisor"
+		}
+		api.put(collection, obj)
+		api.createVdcDefaults(obj)
+		return obj, http.StatusCreated, ""
+	case "network":
+		if api.get("vdc", fakeString(body["vdc"])) == nil {
+			return nil, http.StatusBadRequest, "Unknown vdc"
+		}
+		obj["is_default"] = false
+	case "subnet":
+		obj["dns_servers"] = fakeList(obj["dns_servers"])
+		obj["subnet_routes"] = fakeList(obj["subnet_routes"])
+	case "port":
+		network := api.get("network", fakeString(body["network"]))
+		if network == nil {
+			return nil, http.StatusBadRequest, "Unknown network"
+		}
+		obj["vdc"] = network["vdc"]
+		obj["ip_address"] = api.allocateIP(network, fakeString(body["ip_address"]))
+		obj["fw_templates"] = fakeStrings(obj["fw_templates"])
+		obj["connected"] = api.connectedTarget(body)
+	case "disk":
+		if api.get("storage_profile", fakeString(body["storage_profile"])) == nil {
+			return nil, http.StatusBadRequest, "Unknown storage profile"
+		}
+		if vm := api.get("vm", fakeString(body["vm"])); vm != nil {
+			obj["vdc"] = vm["vdc"]
+		}
+	case "vm":
+		return api.createVm(obj, body)
+	case "router":
+		if api.get("vdc", fakeString(body["vdc"])) == nil {
+			return nil, http.StatusBadRequest, "Unknown vdc"
+		}
+		obj["is_default"] = false
+		obj["floating"] = api.allocateFloating(body["floating"], nil)
+		api.put(collection, obj)
+		for _, port := range fakeList(body["ports"]) {
+			if p := api.get("port", fakeString(port.(map[string]interface{})["id"])); p != nil {
+				p["connected"] = fakeObject{"id": obj["id"], "type": "router"}
+			}
+		}
+		delete(obj, "ports")
+		return obj, http.StatusCreated, ""
+	case "firewall", "dns", "s3_storage":
+		if collection == "s3_storage" {
+			obj["client_endpoint"] = "https://s3.example.com"
+			obj["access_key"] = "FAKEACCESSKEY"
+			obj["secret_key"] = "FAKESECRETKEY"
+		}
+	case "record", "rule", "bucket":
+		delete(obj, "tags")
+		if collection == "bucket" {
+			obj["external_name"] = fmt.Sprintf("%s-%d", fakeString(body["name"]), api.seq)
+		}
+	case "lbaas":
+		portArgs, _ := body["port"].(map[string]interface{})
+		network := api.get("network", fakeString(portArgs["network"]))
+		if network == nil {
+			return nil, http.StatusBadRequest, "Unknown network"
+		}
+		obj["floating"] = api.allocateFloating(body["floating"], nil)
+		api.put(collection, obj)
+		obj["port"] = api.put("port", fakeObject{
+			"network":      network["id"],
+			"vdc":          network["vdc"],
+			"ip_address":   api.allocateIP(network, fakeString(portArgs["ip_address"])),
+			"fw_templates": []string{},
+			"tags":         []string{},
+			"connected":    fakeObject{"id": obj["id"], "type": "lbaas"},
+		})
+		return obj, http.StatusCreated, ""
+	case "pool":
+		delete(obj, "tags")
+		obj["members"] = api.poolMembers(body["members"])
+	case "kubernetes":
+		return api.createKubernetes(obj, body)
+	case "paas_service":
+		delete(obj, "tags")
+		obj["status"] = "ready"
+	}
+
+	api.put(collection, obj)
+	return obj, http.StatusCreated, ""
+}
+
+func (api *fakeRustackAPI) createVdcDefaults(vdc fakeObject) {
+	vdcID := vdc["id"].(string)
+	network := fakeObject{"name": "Сеть", "vdc": vdcID, "is_default": true, "tags": []string{}}
+	networkID := api.put("network", network)
+	api.put("subnet", fakeObject{
+		"network":       networkID,
+		"cidr":          "10.0.0.0/24",
+		"gateway":       "10.0.0.1",
+		"start_ip":      "10.0.0.2",
+		"end_ip":        "10.0.0.254",
+		"enable_dhcp":   true,
+		"dns_servers":   []interface{}{map[string]interface{}{"dns_server": "8.8.8.8"}},
+		"subnet_routes": []interface{}{},
+	})
+	routerID := api.put("router", fakeObject{
+		"name":       "Сетевой маршрутизатор",
+		"vdc":        vdcID,
+		"is_default": true,
+		"tags":       []string{},
+	})
+	api.get("router", routerID)["floating"] = api.allocateFloating("RANDOM_FIP", nil)
+	api.put("port", fakeObject{
+		"network":      networkID,
+		"vdc":          vdcID,
+		"ip_address":   "10.0.0.1",
+		"fw_templates": []string{},
+		"tags":         []string{},
+		"connected":    fakeObject{"id": routerID, "type": "router"},
+	})
+}
+
+func (api *fakeRustackAPI) createVm(obj fakeObject, body map[string]interface{}) (fakeObject, int, string) {
+	if api.get("vdc", fakeString(body["vdc"])) == nil {
+		return nil, http.StatusBadRequest, "Unknown vdc"
+	}
+	if api.get("template", fakeString(body["template"])) == nil {
+		return nil, http.StatusBadRequest, "Unknown template"
+	}
+	obj["power"] = true
+	obj["hotadd_feature"] = false
+	obj["floating"] = api.allocateFloating(body["floating"], nil)
+	delete(obj, "ports")
+	delete(obj, "disks")
+	delete(obj, "metadata")
+	api.put("vm", obj)
+
+	for _, port := range fakeList(body["ports"]) {
+		if p := api.get("port", fakeString(port.(map[string]interface{})["id"])); p != nil {
+			p["connected"] = fakeObject{"id": obj["id"], "type": "vm"}
+		}
+	}
+	for _, disk := range fakeList(body["disks"]) {
+		diskArgs := disk.(map[string]interface{})
+		api.put("disk", fakeObject{
+			"name":            diskArgs["name"],
+			"size":            diskArgs["size"],
+			"storage_profile": diskArgs["storage_profile"],
+			"vdc":             obj["vdc"],
+			"vm":              obj["id"],
+			"tags":            []string{},
+			"_system":         true,
+		})
+	}
+	return obj, http.StatusCreated, ""
+}
+
+func (api *fakeRustackAPI) createKubernetes(obj fakeObject, body map[string]interface{}) (fakeObject, int, string) {
+	vdc := api.get("vdc", fakeString(body["vdc"]))
+	if vdc == nil {
+		return nil, http.StatusBadRequest, "Unknown vdc"
+	}
+	if api.get("kubernetes_template", fakeString(body["template"])) == nil {
+		return nil, http.StatusBadRequest, "Unknown template"
+	}
+	obj["project"] = vdc["project"]
+	obj["floating"] = api.allocateFloating(body["floating"], nil)
+	api.put("kubernetes", obj)
+	api.scaleKubernetes(obj)
+	return obj, http.StatusCreated, ""
+}
+
+// scaleKubernetes keeps the node VMs of a cluster in line with nodes_count.
+func (api *fakeRustackAPI) scaleKubernetes(obj fakeObject) {
+	nodes := api.list("vm", func(vm fakeObject) bool { return vm["kubernetes"] == obj["id"] })
+	count := fakeInt(obj["nodes_count"])
+	for i := len(nodes); i < count; i++ {
+		api.put("vm", fakeObject{
+			"name":           fmt.Sprintf("%s-node-%d", obj["name"], i+1),
+			"cpu":            obj["node_cpu"],
+			"ram":            obj["node_ram"],
+			"vdc":            obj["vdc"],
+			"template":       api.TemplateID,
+			"power":          true,
+			"hotadd_feature": false,
+			"kubernetes":     obj["id"],
+			"tags":           []string{},
+		})
+	}
+	for i := count; i < len(nodes); i++ {
+		api.delete("vm", nodes[i])
+	}
+}
+
+func (api *fakeRustackAPI) update(collection string, obj fakeObject, body map[string]interface{}) (int, string) {
+	switch collection {
+	case "port":
+		if _, ok := body["vm"]; ok {
+			obj["connected"] = api.connectedTarget(body)
+		} else if _, ok := body["router"]; ok {
+			obj["connected"] = api.connectedTarget(body)
+		}
+		if ip := fakeString(body["ip_address"]); ip != "" {
+			obj["ip_address"] = api.allocateIP(api.get("network", obj["network"].(string)), ip)
+		}
+		if _, ok := body["fw_templates"]; ok {
+			obj["fw_templates"] = fakeStrings(body["fw_templates"])
+		}
+		if _, ok := body["tags"]; ok {
+			obj["tags"] = fakeStrings(body["tags"])
+		}
+		return http.StatusOK, ""
+	case "lbaas":
+		obj["name"] = body["name"]
+		obj["tags"] = fakeStrings(body["tags"])
+		if value, ok := body["floating"]; ok {
+			obj["floating"] = api.allocateFloating(value, obj["floating"])
+		}
+		return http.StatusOK, ""
+	case "vm", "router", "kubernetes":
+		if value, ok := body["floating"]; ok {
+			obj["floating"] = api.allocateFloating(value, obj["floating"])
+		}
+		delete(body, "floating")
+		delete(body, "ports")
+		delete(body, "vdc")
+		delete(body, "is_default")
+	case "pool":
+		body["members"] = api.poolMembers(body["members"])
+	}
+
+	delete(body, "id")
+	delete(body, "locked")
+	for key, value := range body {
+		if key == "tags" {
+			value = fakeStrings(value)
+		}
+		obj[key] = value
+	}
+	if collection == "kubernetes" {
+		api.scaleKubernetes(obj)
+	}
+	return http.StatusOK, ""
+}
+
+func (api *fakeRustackAPI) delete(collection string, obj fakeObject) {
+	id := obj["id"].(string)
+	delete(api.objects[collection], id)
+
+	switch collection {
+	case "vm":
+		for _, disk := range api.list("disk", func(disk fakeObject) bool { return disk["vm"] == id }) {
+			api.delete("disk", disk)
+		}
+		api.disconnectPorts(id)
+	case "router", "lbaas":
+		if collection == "lbaas" {
+			if port := api.get("port", fakeString(obj["port"])); port != nil {
+				api.delete("port", port)
+			}
+		}
+		api.disconnectPorts(id)
+	case "kubernetes":
+		for _, vm := range api.list("vm", func(vm fakeObject) bool { return vm["kubernetes"] == id }) {
+			api.delete("vm", vm)
+		}
+	case "vdc":
+		for _, child := range []string{"vm", "disk", "port", "router", "network", "lbaas", "kubernetes", "firewall"} {
+			for _, item := range api.list(child, func(item fakeObject) bool { return item["vdc"] == id }) {
+				if api.get(child, item["id"].(string)) != nil {
+					api.delete(child, item)
+				}
+			}
+		}
+	case "network":
+		for _, subnet := range api.list("subnet", func(subnet fakeObject) bool { return subnet["network"] == id }) {
+			api.delete("subnet", subnet)
+		}
+	}
+}
+
+func (api *fakeRustackAPI) disconnectPorts(ownerID string) {
+	for _, port := range api.list("port", nil) {
+		if connected, ok := port["connected"].(fakeObject); ok && connected["id"] == ownerID {
+			port["connected"] = nil
+		}
+	}
+}
+
+func (api *fakeRustackAPI) connectedTarget(body map[string]interface{}) interface{} {
+	for _, kind := range []string{"vm", "router", "lbaas"} {
+		if id := fakeString(body[kind]); id != "" {
+			return fakeObject{"id": id, "type": kind}
+		}
+	}
+	return nil
+}
+
+func (api *fakeRustackAPI) allocateIP(network fakeObject, requested string) string {
+	if requested != "" && requested != "0.0.0.0" {
+		return requested
+	}
+	api.ipSeq++
+	networkID, _ := network["id"].(string)
+	for _, subnet := range api.list("subnet", func(subnet fakeObject) bool { return subnet["network"] == networkID }) {
+		if _, cidr, err := net.ParseCIDR(fakeString(subnet["cidr"])); err == nil {
+			ip := cidr.IP.To4()
+			return fmt.Sprintf("%d.%d.%d.%d", ip[0], ip[1], ip[2], 10+api.ipSeq%240)
+		}
+	}
+	return fmt.Sprintf("10.0.0.%d", 10+api.ipSeq%240)
+}
+
+// allocateFloating mirrors the floating ip semantics of the API: "RANDOM_FIP"
+// allocates a new address, null releases it and anything else keeps it.
+func (api *fakeRustackAPI) allocateFloating(requested interface{}, current interface{}) interface{} {
+	if requested == nil {
+		return nil
+	}
+	if requested == "RANDOM_FIP" {
+		if current != nil {
+			return current
+		}
+		api.ipSeq++
+		return fakeObject{"id": api.newID(), "ip_address": fmt.Sprintf("203.0.113.%d", api.ipSeq%250+1)}
+	}
+	return current
+}
+
+func (api *fakeRustackAPI) poolMembers(raw interface{}) []interface{} {
+	members := make([]interface{}, 0)
+	for _, member := range fakeList(raw) {
+		memberArgs := member.(map[string]interface{})
+		members = append(members, map[string]interface{}{
+			"id":     api.newID(),
+			"port":   memberArgs["port"],
+			"weight": memberArgs["weight"],
+			"vm":     memberArgs["vm"],
+		})
+	}
+	return members
+}
+
+func (api *fakeRustackAPI) render(collection string, obj fakeObject) map[string]interface{} {
+	result := make(map[string]interface{}, len(obj))
+	for key, value := range obj {
+		if !strings.HasPrefix(key, "_") {
+			result[key] = value
+		}
+	}
+	result["locked"] = false
+	if tags, ok := obj["tags"]; ok {
+		result["tags"] = fakeRenderTags(tags)
+	}
+
+	switch collection {
+	case "project":
+		result["client"] = map[string]interface{}{
+			"id":                  obj["client"],
+			"allowed_hypervisors": api.renderAll("hypervisor", api.list("hypervisor", nil)),
+		}
+	case "vdc":
+		result["hypervisor"] = api.renderRef("hypervisor", obj["hypervisor"])
+		result["project"] = api.renderRef("project", obj["project"])
+	case "network":
+		result["vdc"] = api.renderRef("vdc", obj["vdc"])
+		result["subnets"] = api.renderAll("subnet", api.list("subnet", func(s fakeObject) bool { return s["network"] == obj["id"] }))
+	case "port":
+		result["network"] = api.renderRef("network", obj["network"])
+		fwTemplates := make([]interface{}, 0)
+		for _, id := range fakeStrings(obj["fw_templates"]) {
+			fwTemplates = append(fwTemplates, api.renderRef("firewall", id))
+		}
+		result["fw_templates"] = fwTemplates
+		if connected, ok := obj["connected"].(fakeObject); ok {
+			result["connected"] = map[string]interface{}{
+				"id":   connected["id"],
+				"type": connected["type"],
+				"vdc":  api.renderRef("vdc", obj["vdc"]),
+			}
+		}
+	case "vm":
+		result["vdc"] = api.renderRef("vdc", obj["vdc"])
+		result["template"] = api.renderRef("template", obj["template"])
+		result["ports"] = api.renderAll("port", api.connectedPorts(obj["id"], "vm"))
+		result["disks"] = api.renderAll("disk", api.list("disk", func(d fakeObject) bool { return d["vm"] == obj["id"] }))
+		if k8s := api.get("kubernetes", fakeString(obj["kubernetes"])); k8s != nil {
+			result["kubernetes"] = map[string]interface{}{"id": k8s["id"], "name": k8s["name"]}
+		} else {
+			delete(result, "kubernetes")
+		}
+	case "disk":
+		result["storage_profile"] = api.renderRef("storage_profile", obj["storage_profile"])
+		result["scsi"] = obj["_scsi"]
+		if result["scsi"] == nil {
+			result["scsi"] = "0:1"
+		}
+		result["external_id"] = "vol-" + obj["id"].(string)
+		if vm := api.get("vm", fakeString(obj["vm"])); vm != nil {
+			result["vm"] = map[string]interface{}{"id": vm["id"], "name": vm["name"]}
+		} else {
+			result["vm"] = nil
+		}
+	case "router":
+		result["vdc"] = map[string]interface{}{"id": obj["vdc"]}
+		result["ports"] = api.renderAll("port", api.connectedPorts(obj["id"], "router"))
+	case "dns", "s3_storage":
+		result["project"] = api.renderRef("project", obj["project"])
+	case "lbaas":
+		result["vdc"] = api.renderRef("vdc", obj["vdc"])
+		result["port"] = api.renderRef("port", obj["port"])
+	case "pool":
+		members := make([]interface{}, 0)
+		for _, member := range fakeList(obj["members"]) {
+			memberMap := make(map[string]interface{})
+			for key, value := range member.(map[string]interface{}) {
+				memberMap[key] = value
+			}
+			memberMap["vm"] = map[string]interface{}{"id": memberMap["vm"]}
+			members = append(members, memberMap)
+		}
+		result["members"] = members
+	case "kubernetes":
+		result["vdc"] = api.renderRef("vdc", obj["vdc"])
+		result["project"] = api.renderRef("project", obj["project"])
+		result["template"] = api.renderRef("kubernetes_template", obj["template"])
+		result["node_platform"] = api.renderRef("platform", obj["node_platform"])
+		result["node_storage_profile"] = api.renderRef("storage_profile", obj["node_storage_profile"])
+		result["vms"] = api.renderAll("vm", api.list("vm", func(vm fakeObject) bool { return vm["kubernetes"] == obj["id"] }))
+	case "platform":
+		result["hypervisor"] = api.renderRef("hypervisor", obj["hypervisor"])
+	case "paas_service":
+		result["project"] = api.renderRef("project", obj["project"])
+	}
+
+	return result
+}
+
+func (api *fakeRustackAPI) renderRef(collection string, id interface{}) interface{} {
+	obj := api.get(collection, fakeString(id))
+	if obj == nil {
+		return nil
+	}
+	return api.render(collection, obj)
+}
+
+func (api *fakeRustackAPI) renderAll(collection string, objects []fakeObject) []interface{} {
+	result := make([]interface{}, len(objects))
+	for i, obj := range objects {
+		result[i] = api.render(collection, obj)
+	}
+	return result
+}
+
+func (api *fakeRustackAPI) connectedPorts(ownerID interface{}, kind string) []fakeObject {
+	return api.list("port", func(port fakeObject) bool {
+		connected, ok := port["connected"].(fakeObject)
+		return ok && connected["id"] == ownerID && connected["type"] == kind
+	})
+}
+
+func fakeRenderTags(raw interface{}) []interface{} {
+	tags := make([]interface{}, 0)
+	for _, name := range fakeStrings(raw) {
+		tags = append(tags, map[string]interface{}{"id": name, "name": name})
+	}
+	return tags
+}
+
+func fakeString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return ""
+}
+
+func fakeInt(value interface{}) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+func fakeList(value interface{}) []interface{} {
+	if list, ok := value.([]interface{}); ok {
+		return list
+	}
+	return []interface{}{}
+}
+
+func fakeStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return []string{}
+}
+
+// fakeKubeconfig renders the kubeconfig of a cluster with PEM blocks that
+// name the cluster, so tests can tell the decoded values apart.
+func fakeKubeconfig(id string) fakeRaw {
+	pem := func(kind, content string) string {
+		return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("-----BEGIN %[1]s-----\n%[2]s\n-----END %[1]s-----\n", kind, content)))
+	}
+	return fakeRaw(fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: admin@%[1]s
+clusters:
+- name: %[1]s
+  cluster:
+    server: https://%[1]s.k8s.fake:6443
+    certificate-authority-data: %[2]s
+contexts:
+- name: admin@%[1]s
+  context:
+    cluster: %[1]s
+    user: admin@%[1]s
+users:
+- name: admin@%[1]s
+  user:
+    client-certificate-data: %[3]s
+    client-key-data: %[4]s
+`, id, pem("CERTIFICATE", "ca-"+id), pem("CERTIFICATE", "client-"+id), pem("EC PRIVATE KEY", "key-"+id)))
+}
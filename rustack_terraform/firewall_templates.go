@@ -80,6 +80,7 @@ func (args *Arguments) injectCreateFirewallTemplate() {
 			),
 			Description: "name of the firewall template",
 		},
-		"tags": newTagNamesResourceSchema("tags of the firewall template"),
+		"tags":     newTagNamesResourceSchema("tags of the firewall template"),
+		"tags_all": newTagsAllResourceSchema("tags of the firewall template including the provider default tags"),
 	})
 }
@@ -0,0 +1,116 @@
+package rustack_terraform
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// newHTTPClient builds the client used by rustack.Manager. Requests pass
+// through the retry policy, the rate limit, the request logger and the
+// per-request timeout before they reach the network.
+func (c *Config) newHTTPClient() (*http.Client, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if c.InsecureSkipVerify {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "TLS certificate verification of the Rustack API is disabled",
+			Detail: "insecure_skip_verify is set, so the identity of the API server is not checked and " +
+				"the token can be intercepted. Use ca_file to trust a private CA instead.",
+		})
+	}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(expandHome(c.CAFile))
+		if err != nil {
+			return nil, append(diags, diag.Errorf("ca_file: Error reading CA bundle: %s", err)...)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, append(diags, diag.Errorf("ca_file: No PEM certificates found in %s", c.CAFile)...)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.ClientCertFile != "" || c.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(expandHome(c.ClientCertFile), expandHome(c.ClientKeyFile))
+		if err != nil {
+			return nil, append(diags, diag.Errorf("client_cert_file: Error loading client certificate: %s", err)...)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	if c.ProxyURL != "" {
+		proxy, err := url.Parse(c.ProxyURL)
+		if err != nil || proxy.Host == "" {
+			return nil, append(diags, diag.Errorf("proxy_url: %q is not a valid proxy URL", c.ProxyURL)...)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	return &http.Client{
+		Transport: &retryTransport{
+			next: newRateLimitTransport(&loggingTransport{
+				next: &timeoutTransport{next: transport, timeout: c.RequestTimeout},
+			}, c.MaxRequestsPerSecond),
+			policy: c.Retry,
+		},
+	}, diags
+}
+
+// timeoutTransport limits the time of a single attempt, including reading
+// the response body. Unlike http.Client.Timeout it does not cut the waits
+// between retries short.
+type timeoutTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if ctx.Err() == context.DeadlineExceeded && req.Context().Err() == nil {
+			return nil, fmt.Errorf("%s %s: no response within the request timeout of %s: %w",
+				req.Method, req.URL.Path, t.timeout, err)
+		}
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody releases the timeout of a request once its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
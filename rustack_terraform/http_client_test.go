@@ -0,0 +1,177 @@
+package rustack_terraform
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testClientCertificate creates a self-signed client certificate and returns
+// it together with the paths of its PEM files.
+func testClientCertificate(t *testing.T) (*x509.Certificate, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "terraform"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, writePEM(t, "client.crt", "CERTIFICATE", der), writePEM(t, "client.key", "EC PRIVATE KEY", keyDer)
+}
+
+func testHTTPClient(t *testing.T, config Config) (*http.Client, diag.Diagnostics) {
+	config.Retry = testRetryPolicy()
+	config.Retry.MaxAttempts = 1
+	client, diags := config.newHTTPClient()
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	return client, diags
+}
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(`{}`))
+}
+
+func TestHTTPClient_caFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(okHandler))
+	defer server.Close()
+
+	client, _ := testHTTPClient(t, Config{})
+	if _, err := client.Get(server.URL); err == nil {
+		t.Fatal("expected the self-signed server certificate to be rejected")
+	}
+
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	client, _ = testHTTPClient(t, Config{CAFile: caFile})
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp.Body.Close()
+}
+
+func TestHTTPClient_insecureSkipVerify(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(okHandler))
+	defer server.Close()
+
+	client, diags := testHTTPClient(t, Config{InsecureSkipVerify: true})
+	if len(diags) != 1 || diags[0].Severity != diag.Warning {
+		t.Errorf("expected a warning, got %v", diags)
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp.Body.Close()
+}
+
+func TestHTTPClient_clientCertificate(t *testing.T) {
+	cert, certFile, keyFile := testClientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(okHandler))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+
+	client, _ := testHTTPClient(t, Config{CAFile: caFile})
+	if _, err := client.Get(server.URL); err == nil {
+		t.Fatal("expected the request without a client certificate to be rejected")
+	}
+
+	client, _ = testHTTPClient(t, Config{CAFile: caFile, ClientCertFile: certFile, ClientKeyFile: keyFile})
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp.Body.Close()
+}
+
+func TestHTTPClient_proxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+
+	client, _ := testHTTPClient(t, Config{ProxyURL: proxy.URL})
+	resp, err := client.Get("http://rustack.invalid/v1/account/me")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp.Body.Close()
+	if proxied != "http://rustack.invalid/v1/account/me" {
+		t.Errorf("expected the request to go through the proxy, got %q", proxied)
+	}
+}
+
+func TestHTTPClient_requestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client, _ := testHTTPClient(t, Config{RequestTimeout: 50 * time.Millisecond})
+	_, err := client.Get(server.URL + "/v1/account/me")
+	if err == nil || !strings.Contains(err.Error(), "request timeout of 50ms") {
+		t.Fatalf("expected a request timeout, got %v", err)
+	}
+	if !isTransient(err) {
+		t.Errorf("expected the timeout to be transient: %s", err)
+	}
+}
+
+func TestHTTPClient_invalidFiles(t *testing.T) {
+	cases := map[string]Config{
+		"ca_file: Error reading CA bundle":                   {CAFile: "/nonexistent/ca.pem"},
+		"ca_file: No PEM certificates":                       {CAFile: writeTestFile(t, "not a certificate")},
+		"client_cert_file: Error loading client certificate": {ClientCertFile: "/nonexistent/client.crt", ClientKeyFile: "/nonexistent/client.key"},
+	}
+	for expected, config := range cases {
+		_, diags := config.newHTTPClient()
+		if !diags.HasError() || !strings.Contains(diags[0].Summary, expected) {
+			t.Errorf("expected an error containing %q, got %v", expected, diags)
+		}
+	}
+}
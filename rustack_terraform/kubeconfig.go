@@ -0,0 +1,168 @@
+package rustack_terraform
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/pkg/errors"
+	"github.com/rustack-cloud-platform/rcp-go/rustack"
+	"gopkg.in/yaml.v2"
+)
+
+// kubeconfig is the part of a kubeconfig file needed to connect to the
+// cluster of its current context.
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Contexts       []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Clusters []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKeyData         string `yaml:"client-key-data"`
+			Token                 string `yaml:"token"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
+
+// getKubeconfig downloads the kubeconfig of a cluster. rcp-go only stores it
+// in a file in the working directory, so the request is sent here.
+func getKubeconfig(ctx context.Context, manager *rustack.Manager, id string) (string, error) {
+	requestURL, err := url.JoinPath(manager.BaseURL, "v1/kubernetes", id, "config")
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", manager.Token))
+	req.Header.Set("Accept-Language", "ru-ru")
+
+	resp, err := manager.Client.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "HTTP request failure on %s", requestURL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", rustack.NewRustackApiError(requestURL, resp)
+	}
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrapf(err, "HTTP Read error on response for %s", requestURL)
+	}
+	return string(raw), nil
+}
+
+// flattenKubeconfig returns the raw kubeconfig together with the endpoint
+// and credentials of its current context. The certificates and the key are
+// returned PEM encoded, as the kubernetes and helm providers expect them.
+func flattenKubeconfig(raw string) (map[string]interface{}, error) {
+	result := map[string]interface{}{
+		"kubeconfig":             raw,
+		"host":                   "",
+		"cluster_ca_certificate": "",
+		"client_certificate":     "",
+		"client_key":             "",
+		"token":                  "",
+	}
+	if raw == "" {
+		return result, nil
+	}
+
+	var config kubeconfig
+	if err := yaml.Unmarshal([]byte(raw), &config); err != nil {
+		return nil, errors.Wrap(err, "Error parsing kubeconfig")
+	}
+
+	clusterName, userName := "", ""
+	for _, c := range config.Contexts {
+		if c.Name == config.CurrentContext || (config.CurrentContext == "" && clusterName == "") {
+			clusterName, userName = c.Context.Cluster, c.Context.User
+		}
+	}
+	for _, c := range config.Clusters {
+		if c.Name == clusterName || len(config.Clusters) == 1 {
+			result["host"] = c.Cluster.Server
+			ca, err := base64.StdEncoding.DecodeString(c.Cluster.CertificateAuthorityData)
+			if err != nil {
+				return nil, errors.Wrap(err, "Error decoding certificate-authority-data of the kubeconfig")
+			}
+			result["cluster_ca_certificate"] = string(ca)
+		}
+	}
+	for _, u := range config.Users {
+		if u.Name == userName || len(config.Users) == 1 {
+			cert, err := base64.StdEncoding.DecodeString(u.User.ClientCertificateData)
+			if err != nil {
+				return nil, errors.Wrap(err, "Error decoding client-certificate-data of the kubeconfig")
+			}
+			key, err := base64.StdEncoding.DecodeString(u.User.ClientKeyData)
+			if err != nil {
+				return nil, errors.Wrap(err, "Error decoding client-key-data of the kubeconfig")
+			}
+			result["client_certificate"] = string(cert)
+			result["client_key"] = string(key)
+			result["token"] = u.User.Token
+		}
+	}
+	return result, nil
+}
+
+func (args *Arguments) injectResultKubeconfig() {
+	args.merge(Arguments{
+		"kubeconfig": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Sensitive:   true,
+			Description: "kubeconfig of the kubernetes in YAML",
+		},
+		"host": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Sensitive:   true,
+			Description: "endpoint of the kubernetes API server",
+		},
+		"cluster_ca_certificate": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Sensitive:   true,
+			Description: "PEM encoded CA certificate of the kubernetes API server",
+		},
+		"client_certificate": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Sensitive:   true,
+			Description: "PEM encoded client certificate to authenticate to the kubernetes",
+		},
+		"client_key": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Sensitive:   true,
+			Description: "PEM encoded private key of the client certificate",
+		},
+		"token": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Sensitive:   true,
+			Description: "bearer token to authenticate to the kubernetes. May be empty",
+		},
+	})
+}
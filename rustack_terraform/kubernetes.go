@@ -104,7 +104,8 @@ func (args *Arguments) injectCreateKubernetes() {
 			Computed:    true,
 			Description: "Kubernetes dashboard url",
 		},
-		"tags": newTagNamesResourceSchema("tags of the Kubernetes"),
+		"tags":     newTagNamesResourceSchema("tags of the Kubernetes"),
+		"tags_all": newTagsAllResourceSchema("tags of the Kubernetes including the provider default tags"),
 	})
 }
 
@@ -88,7 +88,8 @@ func (args *Arguments) injectCreateLbaas() {
 			Computed:    true,
 			Description: "floating ip for the Lbaas. May be comitted",
 		},
-		"tags": newTagNamesResourceSchema("tags of the Lbaas"),
+		"tags":     newTagNamesResourceSchema("tags of the Lbaas"),
+		"tags_all": newTagsAllResourceSchema("tags of the Lbaas including the provider default tags"),
 	})
 }
 
@@ -0,0 +1,126 @@
+package rustack_terraform
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Log subsystems of the provider. Besides TF_LOG and TF_LOG_PROVIDER, the
+// level of each one can be set with TF_LOG_PROVIDER_RUSTACK_<SUBSYSTEM>,
+// e.g. TF_LOG_PROVIDER_RUSTACK_HTTP=TRACE shows API request and response
+// bodies.
+const (
+	subsystemHTTP       = "http"
+	subsystemAuth       = "auth"
+	subsystemWait       = "wait"
+	subsystemProject    = "project"
+	subsystemVdc        = "vdc"
+	subsystemVm         = "vm"
+	subsystemDisk       = "disk"
+	subsystemNetwork    = "network"
+	subsystemPort       = "port"
+	subsystemRouter     = "router"
+	subsystemFirewall   = "firewall"
+	subsystemDns        = "dns"
+	subsystemLbaas      = "lbaas"
+	subsystemKubernetes = "kubernetes"
+	subsystemS3         = "s3"
+)
+
+func withSubsystem(ctx context.Context, subsystem string) context.Context {
+	return tflog.NewSubsystem(ctx, subsystem,
+		tflog.WithLevelFromEnv("TF_LOG_PROVIDER_RUSTACK", strings.ToUpper(subsystem)))
+}
+
+func logTrace(ctx context.Context, subsystem, msg string, fields ...map[string]interface{}) {
+	tflog.SubsystemTrace(withSubsystem(ctx, subsystem), subsystem, msg, fields...)
+}
+
+func logDebug(ctx context.Context, subsystem, msg string, fields ...map[string]interface{}) {
+	tflog.SubsystemDebug(withSubsystem(ctx, subsystem), subsystem, msg, fields...)
+}
+
+func logInfo(ctx context.Context, subsystem, msg string, fields ...map[string]interface{}) {
+	tflog.SubsystemInfo(withSubsystem(ctx, subsystem), subsystem, msg, fields...)
+}
+
+func logWarn(ctx context.Context, subsystem, msg string, fields ...map[string]interface{}) {
+	tflog.SubsystemWarn(withSubsystem(ctx, subsystem), subsystem, msg, fields...)
+}
+
+// secretFields matches JSON string values that must never reach the logs.
+var secretFields = regexp.MustCompile(`("(?:token|access_key|secret_key|password)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// kubeconfigPath matches the API path a kubeconfig is downloaded from.
+var kubeconfigPath = regexp.MustCompile(`/kubernetes/[^/]+/config$`)
+
+// maskSecrets hides the values of secret fields in a JSON body.
+func maskSecrets(body []byte) string {
+	return secretFields.ReplaceAllString(string(body), `$1"***"`)
+}
+
+// loggingTransport logs every API request in the http subsystem. Bodies
+// are only logged at TRACE level and with secrets masked; the Authorization
+// header is never logged.
+type loggingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := withSubsystem(req.Context(), subsystemHTTP)
+	fields := map[string]interface{}{
+		"method": req.Method,
+		"path":   req.URL.Path,
+	}
+
+	tflog.SubsystemDebug(ctx, subsystemHTTP, "Sending Rustack API request", fields)
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		if len(body) > 0 {
+			tflog.SubsystemTrace(ctx, subsystemHTTP, "Rustack API request body", fields,
+				map[string]interface{}{"body": maskSecrets(body)})
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	fields["duration"] = time.Since(start).String()
+	if err != nil {
+		tflog.SubsystemDebug(ctx, subsystemHTTP, "Rustack API request failed", fields,
+			map[string]interface{}{"error": err.Error()})
+		return resp, err
+	}
+
+	fields["status_code"] = resp.StatusCode
+	tflog.SubsystemDebug(ctx, subsystemHTTP, "Received Rustack API response", fields)
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) == 0 {
+		return resp, nil
+	}
+	logged := maskSecrets(body)
+	if kubeconfigPath.MatchString(req.URL.Path) {
+		logged = "<kubeconfig omitted>"
+	}
+	tflog.SubsystemTrace(ctx, subsystemHTTP, "Rustack API response body", fields,
+		map[string]interface{}{"body": logged})
+
+	return resp, nil
+}
@@ -0,0 +1,79 @@
+package rustack_terraform
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+func TestMaskSecrets(t *testing.T) {
+	cases := map[string]string{
+		`{"name":"bucket"}`:                         `{"name":"bucket"}`,
+		`{"access_key":"AK","secret_key":"S\"K"}`:   `{"access_key":"***","secret_key":"***"}`,
+		`{"token": "abc", "vm": {"password":"pw"}}`: `{"token": "***", "vm": {"password":"***"}}`,
+	}
+	for body, expected := range cases {
+		if masked := maskSecrets([]byte(body)); masked != expected {
+			t.Errorf("maskSecrets(%s) = %s, want %s", body, masked, expected)
+		}
+	}
+}
+
+func doLoggedRequest(t *testing.T, path, requestBody, responseBody string) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(responseBody))
+	}))
+	t.Cleanup(server.Close)
+
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+path, strings.NewReader(requestBody))
+	req.Header.Set("Authorization", "Bearer api-token")
+	client := &http.Client{Transport: &loggingTransport{next: http.DefaultTransport}}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != responseBody {
+		t.Errorf("response body = %q, want %q", body, responseBody)
+	}
+	return output.String()
+}
+
+func TestLoggingTransport_masksSecrets(t *testing.T) {
+	output := doLoggedRequest(t, "/v1/s3_storage",
+		`{"name":"storage","token":"request-secret"}`,
+		`{"id":"1","access_key":"access-secret","secret_key":"response-secret"}`)
+
+	for _, expected := range []string{"Rustack API request body", "Rustack API response body", `"@module":"provider.http"`} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %s in the log:\n%s", expected, output)
+		}
+	}
+	for _, secret := range []string{"api-token", "request-secret", "access-secret", "response-secret"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("secret %s leaked into the log:\n%s", secret, output)
+		}
+	}
+}
+
+func TestLoggingTransport_omitsKubeconfig(t *testing.T) {
+	output := doLoggedRequest(t, "/v1/kubernetes/1/config", "", "users:\n- user:\n    token: kube-secret\n")
+
+	if strings.Contains(output, "kube-secret") {
+		t.Errorf("kubeconfig leaked into the log:\n%s", output)
+	}
+	if !strings.Contains(output, "kubeconfig omitted") {
+		t.Errorf("expected the kubeconfig to be omitted:\n%s", output)
+	}
+}
@@ -101,7 +101,8 @@ func (args *Arguments) injectCreateNetwork() {
 			Optional: true,
 			Computed: true,
 		},
-		"tags": newTagNamesResourceSchema("tags of the Network"),
+		"tags":     newTagNamesResourceSchema("tags of the Network"),
+		"tags_all": newTagsAllResourceSchema("tags of the Network including the provider default tags"),
 	})
 }
 
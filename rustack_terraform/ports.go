@@ -32,7 +32,8 @@ func (args *Arguments) injectCreatePort() {
 			Description: "list of firewall templates ids of the Port",
 			Elem:        &schema.Schema{Type: schema.TypeString},
 		},
-		"tags": newTagNamesResourceSchema("tags of the Port"),
+		"tags":     newTagNamesResourceSchema("tags of the Port"),
+		"tags_all": newTagsAllResourceSchema("tags of the Port including the provider default tags"),
 	})
 }
 
@@ -86,3 +87,25 @@ func (args *Arguments) injectResultListPort() {
 		},
 	})
 }
+
+func (args *Arguments) injectCreatePortAttachment() {
+	args.merge(Arguments{
+		"port_id": {
+			Type:        schema.TypeString,
+			Required:    true,
+			ForceNew:    true,
+			Description: "id of the Port to connect",
+		},
+		"vm_id": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ExactlyOneOf: []string{"vm_id", "router_id"},
+			Description:  "id of the Vm the Port is connected to",
+		},
+		"router_id": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "id of the Router the Port is connected to",
+		},
+	})
+}
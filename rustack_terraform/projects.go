@@ -33,6 +33,20 @@ func (args *Arguments) injectContextProjectById() {
 	})
 }
 
+// injectContextDefaultProjectById is used by resources, which fall back to
+// the provider default project when project_id is omitted.
+func (args *Arguments) injectContextDefaultProjectById() {
+	args.merge(Arguments{
+		"project_id": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			ForceNew:    true,
+			Description: "id of the Project. Defaults to the provider default project",
+		},
+	})
+}
+
 func (args *Arguments) injectContextProjectByIdOptional() {
 	args.merge(Arguments{
 		"project_id": {
@@ -54,7 +68,8 @@ func (args *Arguments) injectCreateProject() {
 			),
 			Description: "name of the Project",
 		},
-		"tags": newTagNamesResourceSchema("tags of the Project"),
+		"tags":     newTagNamesResourceSchema("tags of the Project"),
+		"tags_all": newTagsAllResourceSchema("tags of the Project including the provider default tags"),
 	})
 }
 
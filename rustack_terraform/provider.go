@@ -2,9 +2,11 @@ package rustack_terraform
 
 import (
 	"context"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func Provider() *schema.Provider {
@@ -16,6 +18,36 @@ func Provider() *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc("RUSTACK_TOKEN", nil),
 				Description: "The token key for API operations.",
 			},
+			"token_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("RUSTACK_TOKEN_FILE", nil),
+				Description: "Path to a file containing the token key. Used when token is not set.",
+			},
+			"token_command": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("RUSTACK_TOKEN_COMMAND", nil),
+				Description: "Shell command printing the token key. Used when neither token nor token_file is set.",
+			},
+			"profile": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("RUSTACK_PROFILE", nil),
+				Description: "Profile of the shared credentials file to read the token key from. Defaults to `default`.",
+			},
+			"shared_credentials_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("RUSTACK_SHARED_CREDENTIALS_FILE", defaultSharedCredentialsFile),
+				Description: "Path to the shared credentials file.",
+			},
+			"skip_credentials_validation": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Skip checking the token against the API when the provider is configured.",
+			},
 			"api_endpoint": {
 				Type:        schema.TypeString,
 				Required:    true,
@@ -28,6 +60,104 @@ func Provider() *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc("RUSTACK_CLIENT_ID", nil),
 				Description: "The client id to use for managing instances.",
 			},
+			"ca_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("RUSTACK_CA_FILE", nil),
+				Description: "Path to a PEM bundle of CA certificates trusted in addition to the system ones.",
+			},
+			"client_cert_file": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("RUSTACK_CLIENT_CERT_FILE", nil),
+				RequiredWith: []string{"client_key_file"},
+				Description:  "Path to a PEM client certificate presented to the API.",
+			},
+			"client_key_file": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("RUSTACK_CLIENT_KEY_FILE", nil),
+				RequiredWith: []string{"client_cert_file"},
+				Description:  "Path to the PEM private key of the client certificate.",
+			},
+			"insecure_skip_verify": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Do not verify the TLS certificate of the API. Only use it for testing.",
+			},
+			"proxy_url": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("RUSTACK_PROXY_URL", nil),
+				ValidateFunc: validation.IsURLWithScheme([]string{"http", "https", "socks5"}),
+				Description:  "URL of the proxy to send API requests through. Defaults to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.",
+			},
+			"request_timeout": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("RUSTACK_REQUEST_TIMEOUT", "0s"),
+				ValidateFunc: validateDuration,
+				Description:  "Maximum duration of a single API request, e.g. `30s`. Retries get a new timeout each. `0s` disables it.",
+			},
+			"max_requests_per_second": {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("RUSTACK_MAX_REQUESTS_PER_SECOND", nil),
+				ValidateFunc: validation.FloatAtLeast(0),
+				Description:  "Maximum number of API requests sent per second. `0` disables the limit.",
+			},
+			"max_concurrent_vdc_changes": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      1,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Maximum number of resources created, updated or deleted at the same time in one VDC or, for VDCs, in one project. Further changes wait in the provider instead of failing on the locked VDC. `0` disables the limit.",
+			},
+			"default_project_id": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"default_project_name"},
+				Description:   "id of the Project used by resources that do not set project_id.",
+			},
+			"default_project_name": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"default_project_id"},
+				Description:   "name of the Project used by resources that do not set project_id.",
+			},
+			"default_vdc_id": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"default_vdc_name"},
+				Description:   "id of the VDC used by resources that do not set vdc_id.",
+			},
+			"default_vdc_name": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"default_vdc_id"},
+				Description:   "name of the VDC used by resources that do not set vdc_id. It is looked up in the default project when one is set.",
+			},
+			"retry": retrySchema(),
+			"default_tags": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Tags added to every resource that supports tags.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"tags": {
+							Type:     schema.TypeSet,
+							Optional: true,
+							Elem: &schema.Schema{
+								Type:        schema.TypeString,
+								Description: "name of the Tag",
+							},
+							Description: "Names of the default tags.",
+						},
+					},
+				},
+			},
 		},
 		DataSourcesMap: map[string]*schema.Resource{
 			"rustack_account": dataSourceRustackAccount(),
@@ -69,6 +199,7 @@ func Provider() *schema.Provider {
 			"rustack_platform":             dataSourceRustackPlatform(),            // 030-resource-get-platform +
 			"rustack_platforms":            dataSourceRustackPlatforms(),           // 030-resource-get-platforms +
 			"rustack_paas_template":        dataSourceRustackPaasTemplate(),
+			"rustack_cloudinit_config":     dataSourceRustackCloudinitConfig(),
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
@@ -89,6 +220,8 @@ func Provider() *schema.Provider {
 			"rustack_s3_storage_bucket":      resourceRustackS3StorageBucket(),  // 029-resource-create-s3-storage-bucket +
 			"rustack_kubernetes":             resourceRustackKubernetes(),       // 030-resource-create-rustack-kubernetes +
 			"rustack_paas_service":           resourceRustackPaasService(),
+			"rustack_disk_attachment":        resourceRustackDiskAttachment(),
+			"rustack_port_attachment":        resourceRustackPortAttachment(),
 		},
 	}
 
@@ -99,19 +232,43 @@ func Provider() *schema.Provider {
 			// We can therefore assume that if it's missing it's 0.10 or 0.11
 			terraformVersion = "1.6"
 		}
-		return providerConfigure(d, terraformVersion)
+		return providerConfigure(ctx, d, terraformVersion)
 	}
 
 	return p
 }
 
-func providerConfigure(d *schema.ResourceData, terraformVersion string) (interface{}, diag.Diagnostics) {
+func providerConfigure(ctx context.Context, d *schema.ResourceData, terraformVersion string) (interface{}, diag.Diagnostics) {
+	token, tokenSource, err := resolveToken(ctx, d)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	// validated by the schema
+	requestTimeout, _ := time.ParseDuration(d.Get("request_timeout").(string))
+
 	config := Config{
-		Token:            d.Get("token").(string),
-		APIEndpoint:      d.Get("api_endpoint").(string),
-		ClientID:         d.Get("client_id").(string),
-		TerraformVersion: terraformVersion,
+		Token:                     token,
+		TokenSource:               tokenSource,
+		APIEndpoint:               d.Get("api_endpoint").(string),
+		ClientID:                  d.Get("client_id").(string),
+		Retry:                     expandRetryPolicy(d),
+		SkipCredentialsValidation: d.Get("skip_credentials_validation").(bool),
+		CAFile:                    d.Get("ca_file").(string),
+		ClientCertFile:            d.Get("client_cert_file").(string),
+		ClientKeyFile:             d.Get("client_key_file").(string),
+		InsecureSkipVerify:        d.Get("insecure_skip_verify").(bool),
+		ProxyURL:                  d.Get("proxy_url").(string),
+		RequestTimeout:            requestTimeout,
+		MaxRequestsPerSecond:      d.Get("max_requests_per_second").(float64),
+		MaxConcurrentVdcChanges:   d.Get("max_concurrent_vdc_changes").(int),
+		DefaultTags:               expandDefaultTags(d),
+		DefaultProjectID:          d.Get("default_project_id").(string),
+		DefaultProjectName:        d.Get("default_project_name").(string),
+		DefaultVdcID:              d.Get("default_vdc_id").(string),
+		DefaultVdcName:            d.Get("default_vdc_name").(string),
+		TerraformVersion:          terraformVersion,
 	}
 
-	return config.Client()
+	return config.Client(ctx)
 }
@@ -0,0 +1,104 @@
+package rustack_terraform
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/rustack-cloud-platform/rcp-go/rustack"
+)
+
+// resolveDefaultContext finds the ids of the provider default project and
+// vdc. Names are looked up once, when the provider is configured.
+func (c *Config) resolveDefaultContext(manager *rustack.Manager) (projectID, vdcID string, diags diag.Diagnostics) {
+	projectID = c.DefaultProjectID
+	if c.DefaultProjectName != "" {
+		projects, err := manager.GetProjects(rustack.Arguments{"name": c.DefaultProjectName})
+		if err != nil {
+			return "", "", apiErrorf("default_project_name: Error getting projects: %s", err)
+		}
+		var matches []string
+		for _, project := range projects {
+			if project.Name == c.DefaultProjectName {
+				matches = append(matches, project.ID)
+			}
+		}
+		if projectID, diags = singleMatch("default_project_name", "project", c.DefaultProjectName, matches); diags != nil {
+			return "", "", diags
+		}
+	}
+
+	vdcID = c.DefaultVdcID
+	if c.DefaultVdcName != "" {
+		vdcs, err := manager.GetVdcs(rustack.Arguments{"name": c.DefaultVdcName})
+		if err != nil {
+			return "", "", apiErrorf("default_vdc_name: Error getting vdcs: %s", err)
+		}
+		var matches []string
+		for _, vdc := range vdcs {
+			if vdc.Name == c.DefaultVdcName && (projectID == "" || vdc.Project.ID == projectID) {
+				matches = append(matches, vdc.ID)
+			}
+		}
+		if vdcID, diags = singleMatch("default_vdc_name", "vdc", c.DefaultVdcName, matches); diags != nil {
+			return "", "", diags
+		}
+	}
+
+	return projectID, vdcID, nil
+}
+
+func singleMatch(key, kind, name string, ids []string) (string, diag.Diagnostics) {
+	switch len(ids) {
+	case 0:
+		return "", diag.Errorf("%s: No %s named %q found", key, kind, name)
+	case 1:
+		return ids[0], nil
+	}
+	return "", diag.Errorf("%s: %d objects of kind %s are named %q, use the id instead", key, len(ids), kind, name)
+}
+
+// customizeDiffDefaultProject fills an omitted project_id from the provider
+// default project.
+func customizeDiffDefaultProject(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	return diffDefaultID(d, "project_id", "default_project_id or default_project_name", func(c *CombinedConfig) string {
+		return c.defaultProjectID
+	}, meta)
+}
+
+// customizeDiffDefaultVdc fills an omitted vdc_id from the provider default
+// vdc.
+func customizeDiffDefaultVdc(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	return diffDefaultID(d, "vdc_id", "default_vdc_id or default_vdc_name", func(c *CombinedConfig) string {
+		return c.defaultVdcID
+	}, meta)
+}
+
+// diffDefaultID plans the provider default for a context id that is not set
+// in the configuration. The id is stored in the state like a configured one,
+// so a later change of the default shows up as a replacement in the plan.
+func diffDefaultID(d *schema.ResourceDiff, key, providerKeys string, defaultID func(*CombinedConfig) string, meta interface{}) error {
+	config := d.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() || !config.GetAttr(key).IsNull() {
+		return nil
+	}
+
+	current := d.Get(key).(string)
+	// An imported object whose context the API does not report keeps it empty
+	if d.Id() != "" && current == "" {
+		return nil
+	}
+
+	id := ""
+	if c, ok := meta.(*CombinedConfig); ok {
+		id = defaultID(c)
+	}
+	if id == "" {
+		return fmt.Errorf("%s: Required unless the provider sets %s", key, providerKeys)
+	}
+	if current == id {
+		return nil
+	}
+	return d.SetNew(key, id)
+}
@@ -0,0 +1,336 @@
+package rustack_terraform
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+var testAccProviderFactories = map[string]func() (*schema.Provider, error){
+	"rustack": func() (*schema.Provider, error) {
+		return Provider(), nil
+	},
+}
+
+func TestProvider(t *testing.T) {
+	if err := Provider().InternalValidate(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestAccProvider_retry(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_project", "project"),
+		Steps: []resource.TestStep{
+			{
+				PreConfig: func() { api.Fail(http.StatusTooManyRequests, 2) },
+				Config: fmt.Sprintf(`
+provider "rustack" {
+  api_endpoint = %q
+  token        = %q
+
+  retry {
+    max_attempts = 3
+    base_backoff = "10ms"
+    max_backoff  = "50ms"
+  }
+}
+
+resource "rustack_project" "test" {
+  name = "terraform-acc"
+}
+`, api.server.URL, api.token),
+				Check: testAccCheckExists(api, "rustack_project.test", "project"),
+			},
+			{
+				PreConfig: func() { api.Fail(http.StatusBadRequest, 1) },
+				Config: fmt.Sprintf(`
+provider "rustack" {
+  api_endpoint = %q
+  token        = %q
+}
+
+resource "rustack_project" "test" {
+  name = "terraform-acc-renamed"
+}
+`, api.server.URL, api.token),
+				ExpectError: regexp.MustCompile(`Bad Request`),
+			},
+		},
+	})
+}
+
+func TestAccProvider_tokenFile(t *testing.T) {
+	api := newFakeRustackAPI(t)
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte(api.token+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("RUSTACK_TOKEN", "")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "rustack" {
+  api_endpoint = %q
+  token_file   = %q
+}
+
+data "rustack_account" "me" {}
+`, api.server.URL, tokenFile),
+				Check: resource.TestCheckResourceAttr("data.rustack_account.me", "username", "terraform"),
+			},
+		},
+	})
+}
+
+func TestAccProvider_invalidToken(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "rustack" {
+  api_endpoint = %q
+  token        = "wrong-token"
+}
+
+data "rustack_account" "me" {}
+`, api.server.URL),
+				ExpectError: regexp.MustCompile(`Error validating the API token from token`),
+			},
+		},
+	})
+}
+
+func TestAccProvider_defaultTags(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_project", "project"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderDefaultTagsConfig(api, `["team", "env"]`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckTags(api, "rustack_project.test", "project", "acc", "env", "team"),
+					resource.TestCheckResourceAttr("rustack_project.test", "tags.#", "2"),
+					resource.TestCheckResourceAttr("rustack_project.test", "tags_all.#", "3"),
+					testAccCheckTags(api, "rustack_vdc.test", "vdc", "env", "team"),
+					resource.TestCheckResourceAttr("rustack_vdc.test", "tags.#", "0"),
+					resource.TestCheckResourceAttr("rustack_vdc.test", "tags_all.#", "2"),
+				),
+			},
+			{
+				// a tag set on the resource and in default_tags causes no diff
+				Config:   testAccProviderDefaultTagsConfig(api, `["team", "env"]`),
+				PlanOnly: true,
+			},
+			{
+				Config: testAccProviderDefaultTagsConfig(api, `["team"]`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckTags(api, "rustack_project.test", "project", "acc", "team"),
+					resource.TestCheckResourceAttr("rustack_project.test", "tags.#", "2"),
+					resource.TestCheckResourceAttr("rustack_project.test", "tags_all.#", "2"),
+					testAccCheckTags(api, "rustack_vdc.test", "vdc", "team"),
+				),
+			},
+		},
+	})
+}
+
+func testAccProviderDefaultTagsConfig(api *fakeRustackAPI, defaultTags string) string {
+	return fmt.Sprintf(`
+provider "rustack" {
+  api_endpoint = %q
+  token        = %q
+
+  default_tags {
+    tags = %s
+  }
+}
+
+resource "rustack_project" "test" {
+  name = "terraform-acc"
+  tags = ["acc", "team"]
+}
+
+resource "rustack_vdc" "test" {
+  name          = "terraform-acc"
+  project_id    = rustack_project.test.id
+  hypervisor_id = %q
+}
+`, api.server.URL, api.token, defaultTags, api.HypervisorID)
+}
+
+func TestAccProvider_defaultContext(t *testing.T) {
+	api := newFakeRustackAPI(t)
+	projectID, vdcID := api.CreateVdc("terraform-acc", "terraform-acc")
+	_, otherVdcID := api.CreateVdc("terraform-acc-other", "terraform-acc-other")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_network", "network"),
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccProviderDefaultContextConfig(api, ""),
+				ExpectError: regexp.MustCompile(`vdc_id: Required unless the provider sets default_vdc_id`),
+			},
+			{
+				Config: testAccProviderDefaultContextConfig(api, `
+  default_project_name = "terraform-acc"
+  default_vdc_name     = "terraform-acc"`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_network.test", "vdc_id", vdcID),
+					resource.TestCheckResourceAttr("rustack_s3_storage.test", "project_id", projectID),
+				),
+			},
+			{
+				Config: testAccProviderDefaultContextConfig(api, fmt.Sprintf(`
+  default_project_id = %q
+  default_vdc_id     = %q`, projectID, vdcID)),
+				PlanOnly: true,
+			},
+			{
+				// switching the default replaces the resources in it
+				Config: testAccProviderDefaultContextConfig(api, fmt.Sprintf(`
+  default_project_id = %q
+  default_vdc_id     = %q`, projectID, otherVdcID)),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: testAccProviderDefaultContextConfig(api, fmt.Sprintf(`
+  default_project_id = %q
+  default_vdc_id     = %q`, projectID, otherVdcID)),
+				Check: resource.TestCheckResourceAttr("rustack_network.test", "vdc_id", otherVdcID),
+			},
+		},
+	})
+}
+
+func testAccProviderDefaultContextConfig(api *fakeRustackAPI, defaults string) string {
+	return fmt.Sprintf(`
+provider "rustack" {
+  api_endpoint = %q
+  token        = %q
+%s
+}
+
+resource "rustack_network" "test" {
+  name = "terraform-acc"
+
+  subnets {
+    cidr     = "10.20.0.0/24"
+    dhcp     = true
+    gateway  = "10.20.0.1"
+    start_ip = "10.20.0.2"
+    end_ip   = "10.20.0.100"
+    dns      = ["8.8.8.8"]
+  }
+}
+
+resource "rustack_s3_storage" "test" {
+  name    = "terraform-acc"
+  backend = "minio"
+}
+`, api.server.URL, api.token, defaults)
+}
+
+// testAccCheckTags verifies the tags a resource has in the fake API.
+func testAccCheckTags(api *fakeRustackAPI, name, collection string, expected ...string) func(*terraform.State) error {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("Not found: %s", name)
+		}
+		tags := []string{}
+		for _, tag := range api.Object(collection, rs.Primary.ID)["tags"].([]interface{}) {
+			tags = append(tags, tag.(map[string]interface{})["name"].(string))
+		}
+		sort.Strings(tags)
+		if strings.Join(tags, ",") != strings.Join(expected, ",") {
+			return fmt.Errorf("%s has tags %v in the API, want %v", name, tags, expected)
+		}
+		return nil
+	}
+}
+
+// testAccPreCheck skips acceptance tests unless TF_ACC is set. The tests run
+// against the in-process fake API, so no credentials are required.
+func testAccPreCheck(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("Acceptance tests skipped unless env 'TF_ACC' set")
+	}
+}
+
+// testAccBaseConfig returns the provider block together with a project and a
+// vdc that the other resource tests can attach their objects to.
+func testAccBaseConfig(api *fakeRustackAPI) string {
+	return api.providerConfig() + fmt.Sprintf(`
+resource "rustack_project" "test" {
+  name = "terraform-acc"
+}
+
+resource "rustack_vdc" "test" {
+  name          = "terraform-acc"
+  project_id    = rustack_project.test.id
+  hypervisor_id = %q
+}
+`, api.HypervisorID)
+}
+
+// testAccCheckDestroyed verifies that every resource of the given type was
+// removed from the fake API.
+func testAccCheckDestroyed(api *fakeRustackAPI, resourceType, collection string) func(*terraform.State) error {
+	return func(s *terraform.State) error {
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != resourceType {
+				continue
+			}
+			if api.Exists(collection, rs.Primary.ID) {
+				return fmt.Errorf("%s %s still exists", resourceType, rs.Primary.ID)
+			}
+		}
+		return nil
+	}
+}
+
+// testAccCheckExists verifies that a resource from the state is present in the
+// fake API.
+func testAccCheckExists(api *fakeRustackAPI, name, collection string) func(*terraform.State) error {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("Not found: %s", name)
+		}
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set for %s", name)
+		}
+		if !api.Exists(collection, rs.Primary.ID) {
+			return fmt.Errorf("%s %s not found in the API", name, rs.Primary.ID)
+		}
+		return nil
+	}
+}
@@ -2,10 +2,10 @@ package rustack_terraform
 
 import (
 	"context"
-	"log"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/rustack-cloud-platform/rcp-go/rustack"
 )
@@ -13,61 +13,68 @@ import (
 func resourceRustackDisk() *schema.Resource {
 	args := Defaults()
 	args.injectCreateDisk()
-	args.injectContextVdcById()
+	args.injectContextDefaultVdcById()
 	args.injectContextStorageProfileById() // override storage_profile_id
 
 	return &schema.Resource{
-		CreateContext: resourceRustackDiskCreate,
+		CreateContext: serializeInVdc(resourceRustackDiskCreate),
 		ReadContext:   resourceRustackDiskRead,
-		UpdateContext: resourceRustackDiskUpdate,
-		DeleteContext: resourceRustackDiskDelete,
+		UpdateContext: serializeInVdc(resourceRustackDiskUpdate),
+		DeleteContext: serializeInVdc(resourceRustackDiskDelete),
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
 			Delete: schema.DefaultTimeout(10 * time.Minute),
 		},
-		Schema: args,
+		Schema:        args,
+		CustomizeDiff: customdiff.All(customizeDiffDefaultVdc, customizeDiffTags),
 	}
 }
 
 func resourceRustackDiskCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("vdc_id: Error getting VDC: %s", err)
+		return apiErrorf("vdc_id: Error getting VDC: %s", err)
 	}
 
 	targetStorageProfile, err := GetStorageProfileById(d.Get("storage_profile_id").(string), manager, targetVdc)
 	if err != nil {
-		return diag.Errorf("storage_profile: Error getting storage profile: %s", err)
+		return apiErrorf("storage_profile: Error getting storage profile: %s", err)
 	}
 
 	newDisk := rustack.NewDisk(d.Get("name").(string), d.Get("size").(int), targetStorageProfile)
-	targetVdc.WaitLock()
-	newDisk.Tags = unmarshalTagNames(d.Get("tags"))
+	if err := waitLock(ctx, manager, targetVdc); err != nil {
+		return apiErrorDiag(err)
+	}
+	newDisk.Tags = expandTags(d, meta)
 	err = targetVdc.CreateDisk(&newDisk)
 	if err != nil {
-		return diag.Errorf("Error creating disk: %s", err)
+		return apiErrorf("Error creating disk: %s", err)
+	}
+	if err := waitLock(ctx, manager, &newDisk); err != nil {
+		return apiErrorDiag(err)
 	}
-	newDisk.WaitLock()
 
 	d.SetId(newDisk.ID)
-	log.Printf("[INFO] Disk created, ID: %s", d.Id())
+	logInfo(ctx, subsystemDisk, "Disk created", map[string]interface{}{"id": d.Id()})
 
 	return resourceRustackDiskRead(ctx, d, meta)
 }
 
 func resourceRustackDiskRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	disk, err := manager.GetDisk(d.Id())
 	if err != nil {
-		if err.(*rustack.RustackApiError).Code() == 404 {
+		if isNotFound(err) {
 			d.SetId("")
 			return nil
 		} else {
-			return diag.Errorf("id: Error getting disk: %s", err)
+			return apiErrorf("id: Error getting disk: %s", err)
 		}
 	}
 
@@ -75,16 +82,18 @@ func resourceRustackDiskRead(ctx context.Context, d *schema.ResourceData, meta i
 	d.Set("name", disk.Name)
 	d.Set("size", disk.Size)
 	d.Set("external_id", disk.ExternalID)
-	d.Set("tags", marshalTagNames(disk.Tags))
+	if err := setTags(d, meta, disk.Tags); err != nil {
+		return apiErrorDiag(err)
+	}
 
 	return nil
 }
 
 func resourceRustackDiskUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	disk, err := manager.GetDisk(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Error getting disk: %s", err)
+		return apiErrorf("id: Error getting disk: %s", err)
 	}
 
 	shouldUpdate := false
@@ -93,19 +102,21 @@ func resourceRustackDiskUpdate(ctx context.Context, d *schema.ResourceData, meta
 		shouldUpdate = true
 	}
 
-	if d.HasChange("tags") {
-		disk.Tags = unmarshalTagNames(d.Get("tags"))
+	if d.HasChange("tags_all") {
+		disk.Tags = expandTags(d, meta)
 		shouldUpdate = true
 	}
 
 	if d.HasChange("size") {
 		disk.Size = d.Get("size").(int)
 		if disk.Locked {
-			disk.WaitLock()
+			if err := waitLock(ctx, manager, disk); err != nil {
+				return apiErrorDiag(err)
+			}
 		}
 		err = disk.Resize(d.Get("size").(int))
 		if err != nil {
-			return diag.Errorf("size: Error resizing disk: %s", err)
+			return apiErrorf("size: Error resizing disk: %s", err)
 		}
 		shouldUpdate = false
 	}
@@ -113,26 +124,30 @@ func resourceRustackDiskUpdate(ctx context.Context, d *schema.ResourceData, meta
 	if d.HasChange("storage_profile_id") {
 		targetVdc, err := GetVdcById(d, manager)
 		if err != nil {
-			return diag.Errorf("Error getting VDC: %s", err)
+			return apiErrorf("Error getting VDC: %s", err)
 		}
 
 		targetStorageProfileId := d.Get("storage_profile_id").(string)
 		targetStorageProfile, err := GetStorageProfileById(targetStorageProfileId, manager, targetVdc)
 		if err != nil {
-			return diag.Errorf("storage_profile: Error getting storage profile: %s", err)
+			return apiErrorf("storage_profile: Error getting storage profile: %s", err)
 		}
 		if disk.Locked {
-			disk.WaitLock()
+			if err := waitLock(ctx, manager, disk); err != nil {
+				return apiErrorDiag(err)
+			}
 		}
 		err = disk.UpdateStorageProfile(*targetStorageProfile)
 		if err != nil {
-			return diag.Errorf("storage_profile: Error updating storage: %s", err)
+			return apiErrorf("storage_profile: Error updating storage: %s", err)
 		}
 		shouldUpdate = false
 	}
 	if shouldUpdate {
 		if disk.Locked {
-			disk.WaitLock()
+			if err := waitLock(ctx, manager, disk); err != nil {
+				return apiErrorDiag(err)
+			}
 		}
 		disk.Update()
 	}
@@ -141,27 +156,29 @@ func resourceRustackDiskUpdate(ctx context.Context, d *schema.ResourceData, meta
 }
 
 func resourceRustackDiskDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	disk, err := manager.GetDisk(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Error getting disk: %s", err)
+		return apiErrorf("id: Error getting disk: %s", err)
 	}
 
 	if disk.Vm != nil {
 		vm, err := manager.GetVm(disk.Vm.ID)
 		if err != nil {
-			return diag.FromErr(err)
+			return apiErrorDiag(err)
 		}
 		err = vm.DetachDisk(disk)
 		if err != nil {
-			return diag.FromErr(err)
+			return apiErrorDiag(err)
 		}
 	}
 	err = disk.Delete()
 	if err != nil {
-		return diag.Errorf("Error deleting disk: %s", err)
+		return apiErrorf("Error deleting disk: %s", err)
+	}
+	if err := waitLock(ctx, manager, disk); err != nil {
+		return apiErrorDiag(err)
 	}
-	disk.WaitLock()
 
 	return nil
 }
@@ -0,0 +1,175 @@
+package rustack_terraform
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/rustack-cloud-platform/rcp-go/rustack"
+)
+
+func resourceRustackDiskAttachment() *schema.Resource {
+	args := Defaults()
+	args.injectCreateDiskAttachment()
+
+	return &schema.Resource{
+		CreateContext: serializeIn("vm_id", "vm", resourceRustackDiskAttachmentCreate),
+		ReadContext:   resourceRustackDiskAttachmentRead,
+		UpdateContext: serializeIn("vm_id", "vm", resourceRustackDiskAttachmentUpdate),
+		DeleteContext: serializeIn("vm_id", "vm", resourceRustackDiskAttachmentDelete),
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+		Schema: args,
+	}
+}
+
+func resourceRustackDiskAttachmentCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
+	disk, err := manager.GetDisk(d.Get("disk_id").(string))
+	if err != nil {
+		return apiErrorf("disk_id: Error getting disk: %s", err)
+	}
+
+	if diags := attachDisk(ctx, d, manager, disk, ""); diags.HasError() {
+		return diags
+	}
+
+	// A disk is attached to one vm at most, so it identifies the attachment
+	d.SetId(disk.ID)
+	logInfo(ctx, subsystemDisk, "Disk attached", map[string]interface{}{"disk_id": disk.ID, "vm_id": d.Get("vm_id")})
+
+	return resourceRustackDiskAttachmentRead(ctx, d, meta)
+}
+
+func resourceRustackDiskAttachmentRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
+	disk, err := manager.GetDisk(d.Id())
+	if err != nil {
+		if isNotFound(err) {
+			d.SetId("")
+			return nil
+		} else {
+			return apiErrorf("id: Error getting disk: %s", err)
+		}
+	}
+	if disk.Vm == nil {
+		logWarn(ctx, subsystemDisk, "Disk is no longer attached, removing the attachment from the state", map[string]interface{}{"disk_id": disk.ID})
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("disk_id", disk.ID)
+	d.Set("vm_id", disk.Vm.ID)
+	d.Set("scsi", disk.Scsi)
+
+	return nil
+}
+
+func resourceRustackDiskAttachmentUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
+	if d.HasChange("vm_id") {
+		disk, err := manager.GetDisk(d.Id())
+		if err != nil {
+			return apiErrorf("id: Error getting disk: %s", err)
+		}
+		oldVmID, _ := d.GetChange("vm_id")
+		if diags := attachDisk(ctx, d, manager, disk, oldVmID.(string)); diags.HasError() {
+			return diags
+		}
+		logInfo(ctx, subsystemDisk, "Disk moved", map[string]interface{}{"disk_id": disk.ID, "from": oldVmID, "to": d.Get("vm_id")})
+	}
+
+	return resourceRustackDiskAttachmentRead(ctx, d, meta)
+}
+
+func resourceRustackDiskAttachmentDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
+	disk, err := manager.GetDisk(d.Id())
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return apiErrorf("id: Error getting disk: %s", err)
+	}
+
+	// The disk may have been moved by someone else in the meantime, that
+	// attachment is not ours to remove
+	if disk.Vm == nil || disk.Vm.ID != d.Get("vm_id").(string) {
+		return nil
+	}
+	if err := detachDisk(ctx, manager, disk); err != nil {
+		return apiErrorf("Error detaching disk: %s", err)
+	}
+
+	return nil
+}
+
+// attachDisk attaches the disk to the vm of vm_id. A disk attached to
+// another vm is only detached first when it is attached to ownedVmID, the
+// previous vm of the attachment, or when force_detach is set.
+func attachDisk(ctx context.Context, d *schema.ResourceData, manager *rustack.Manager, disk *rustack.Disk, ownedVmID string) diag.Diagnostics {
+	vm, err := manager.GetVm(d.Get("vm_id").(string))
+	if err != nil {
+		return apiErrorf("vm_id: Error getting vm: %s", err)
+	}
+
+	if disk.Vm != nil {
+		if disk.Vm.ID == vm.ID {
+			return nil
+		}
+		if disk.Vm.ID != ownedVmID && !d.Get("force_detach").(bool) {
+			return diag.Errorf("disk_id: Disk %q is attached to vm %q (%s), set force_detach = true to move it",
+				disk.Name, disk.Vm.Name, disk.Vm.ID)
+		}
+		if err := detachDisk(ctx, manager, disk); err != nil {
+			return apiErrorf("Error detaching disk from vm %s: %s", disk.Vm.ID, err)
+		}
+	}
+
+	attach := func() error { return vm.AttachDisk(disk) }
+	if scsi := d.Get("scsi").(string); scsi != "" {
+		attach = func() error { return attachDiskToSlot(manager, vm, disk, scsi) }
+	}
+	if err := runUnlocked(ctx, manager, attach, vm); err != nil {
+		return apiErrorf("Error attaching disk: %s", err)
+	}
+	if err := waitLock(ctx, manager, vm); err != nil {
+		return apiErrorDiag(err)
+	}
+	return nil
+}
+
+// attachDiskToSlot attaches the disk like Vm.AttachDisk and asks the platform
+// for the given bus and slot, which rcp-go does not send.
+func attachDiskToSlot(manager *rustack.Manager, vm *rustack.Vm, disk *rustack.Disk, scsi string) error {
+	args := &struct {
+		Vm   string `json:"vm"`
+		Scsi string `json:"scsi"`
+	}{
+		Vm:   vm.ID,
+		Scsi: scsi,
+	}
+	return manager.Request("POST", fmt.Sprintf("v1/disk/%s/attach", disk.ID), args, nil)
+}
+
+// detachDisk detaches the disk from its vm and waits for the vm.
+func detachDisk(ctx context.Context, manager *rustack.Manager, disk *rustack.Disk) error {
+	vm, err := manager.GetVm(disk.Vm.ID)
+	if err != nil {
+		return err
+	}
+	detach := func() error { return vm.DetachDisk(disk) }
+	if err := runUnlocked(ctx, manager, detach, vm); err != nil {
+		return err
+	}
+	return waitLock(ctx, manager, vm)
+}
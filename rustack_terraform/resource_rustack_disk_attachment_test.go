@@ -0,0 +1,166 @@
+package rustack_terraform
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccRustackDiskAttachment_basic(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_disk", "disk"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackDiskAttachmentConfig(api, `
+resource "rustack_disk_attachment" "test" {
+  disk_id = rustack_disk.test.id
+  vm_id   = rustack_vm.a.id
+}
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("rustack_disk_attachment.test", "vm_id", "rustack_vm.a", "id"),
+					resource.TestCheckResourceAttrPair("rustack_disk_attachment.test", "id", "rustack_disk.test", "id"),
+					resource.TestCheckResourceAttr("rustack_disk_attachment.test", "scsi", "0:1"),
+					testAccCheckDiskAttachedTo(api, "rustack_disk.test", "rustack_vm.a"),
+				),
+			},
+			{
+				Config: testAccRustackDiskAttachmentConfig(api, `
+resource "rustack_disk_attachment" "test" {
+  disk_id = rustack_disk.test.id
+  vm_id   = rustack_vm.b.id
+}
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("rustack_disk_attachment.test", "vm_id", "rustack_vm.b", "id"),
+					testAccCheckDiskAttachedTo(api, "rustack_disk.test", "rustack_vm.b"),
+				),
+			},
+			{
+				Config: testAccRustackDiskAttachmentConfig(api, `
+resource "rustack_disk_attachment" "test" {
+  disk_id = rustack_disk.test.id
+  vm_id   = rustack_vm.b.id
+  scsi    = "0:2"
+}
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_disk_attachment.test", "scsi", "0:2"),
+					testAccCheckDiskAttachedTo(api, "rustack_disk.test", "rustack_vm.b"),
+				),
+			},
+			{
+				ResourceName:            "rustack_disk_attachment.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"force_detach"},
+			},
+		},
+	})
+}
+
+func TestAccRustackDiskAttachment_forceDetach(t *testing.T) {
+	api := newFakeRustackAPI(t)
+	first := `
+resource "rustack_disk_attachment" "first" {
+  disk_id = rustack_disk.test.id
+  vm_id   = rustack_vm.a.id
+}
+`
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_disk", "disk"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackDiskAttachmentConfig(api, first),
+			},
+			{
+				Config: testAccRustackDiskAttachmentConfig(api, first+`
+resource "rustack_disk_attachment" "second" {
+  disk_id    = rustack_disk.test.id
+  vm_id      = rustack_vm.b.id
+  depends_on = [rustack_disk_attachment.first]
+}
+`),
+				ExpectError: regexp.MustCompile(`disk_id: Disk "terraform-acc-data" is attached to vm "terraform-acc-a" .* set force_detach = true to move it`),
+			},
+			{
+				Config: testAccRustackDiskAttachmentConfig(api, `
+resource "rustack_disk_attachment" "second" {
+  disk_id      = rustack_disk.test.id
+  vm_id        = rustack_vm.b.id
+  force_detach = true
+}
+`),
+				Check: testAccCheckDiskAttachedTo(api, "rustack_disk.test", "rustack_vm.b"),
+			},
+		},
+	})
+}
+
+// testAccCheckDiskAttachedTo verifies the vm a disk is attached to in the
+// fake API.
+func testAccCheckDiskAttachedTo(api *fakeRustackAPI, diskName, vmName string) func(*terraform.State) error {
+	return func(s *terraform.State) error {
+		disk, ok := s.RootModule().Resources[diskName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", diskName)
+		}
+		vm, ok := s.RootModule().Resources[vmName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", vmName)
+		}
+		attached, _ := api.Object("disk", disk.Primary.ID)["vm"].(map[string]interface{})
+		if attached == nil || attached["id"] != vm.Primary.ID {
+			return fmt.Errorf("%s is attached to %v in the API, want %s", diskName, attached, vm.Primary.ID)
+		}
+		return nil
+	}
+}
+
+func testAccRustackDiskAttachmentConfig(api *fakeRustackAPI, attachments string) string {
+	config := testAccBaseConfig(api) + fmt.Sprintf(`
+resource "rustack_disk" "test" {
+  vdc_id             = rustack_vdc.test.id
+  name               = "terraform-acc-data"
+  size               = 5
+  storage_profile_id = %q
+}
+`, api.StorageProfileID)
+	for _, name := range []string{"a", "b"} {
+		config += fmt.Sprintf(`
+resource "rustack_port" "%[1]s" {
+  vdc_id     = rustack_vdc.test.id
+  network_id = rustack_vdc.test.default_network_id
+}
+
+resource "rustack_vm" "%[1]s" {
+  vdc_id      = rustack_vdc.test.id
+  name        = "terraform-acc-%[1]s"
+  cpu         = 1
+  ram         = 1
+  template_id = %[2]q
+  user_data   = "#cloud-config"
+
+  system_disk {
+    size               = 10
+    storage_profile_id = %[3]q
+  }
+
+  networks {
+    id = rustack_port.%[1]s.id
+  }
+}
+`, name, api.TemplateID, api.StorageProfileID)
+	}
+	return config + attachments
+}
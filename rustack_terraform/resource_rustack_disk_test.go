@@ -0,0 +1,54 @@
+package rustack_terraform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccRustackDisk_basic(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_disk", "disk"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackDiskConfig(api, "terraform-acc", 10),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckExists(api, "rustack_disk.test", "disk"),
+					resource.TestCheckResourceAttr("rustack_disk.test", "name", "terraform-acc"),
+					resource.TestCheckResourceAttr("rustack_disk.test", "size", "10"),
+					resource.TestCheckResourceAttr("rustack_disk.test", "storage_profile_id", api.StorageProfileID),
+				),
+			},
+			{
+				Config: testAccRustackDiskConfig(api, "terraform-acc-resized", 20),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_disk.test", "name", "terraform-acc-resized"),
+					resource.TestCheckResourceAttr("rustack_disk.test", "size", "20"),
+				),
+			},
+			{
+				ResourceName:            "rustack_disk.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"vdc_id", "storage_profile_id"},
+			},
+		},
+	})
+}
+
+func testAccRustackDiskConfig(api *fakeRustackAPI, name string, size int) string {
+	return testAccBaseConfig(api) + fmt.Sprintf(`
+resource "rustack_disk" "test" {
+  vdc_id             = rustack_vdc.test.id
+  name               = %q
+  size               = %d
+  storage_profile_id = %q
+  tags               = ["acc"]
+}
+`, name, size, api.StorageProfileID)
+}
@@ -2,7 +2,6 @@ package rustack_terraform
 
 import (
 	"context"
-	"log"
 	"strings"
 	"time"
 
@@ -24,17 +23,19 @@ func resourceRustackDns() *schema.Resource {
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
 			Delete: schema.DefaultTimeout(10 * time.Minute),
 		},
-		Schema: args,
+		Schema:        args,
+		CustomizeDiff: customizeDiffDefaultProject,
 	}
 }
 
 func resourceRustackDnsCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	project, err := GetProjectById(d, manager)
 	if err != nil {
-		return diag.Errorf("project_id: Error getting Project: %s", err)
+		return apiErrorf("project_id: Error getting Project: %s", err)
 	}
 	name := d.Get("name").(string)
 	newDns := rustack.NewDns(name)
@@ -45,45 +46,45 @@ func resourceRustackDnsCreate(ctx context.Context, d *schema.ResourceData, meta
 
 	err = project.CreateDns(&newDns)
 	if err != nil {
-		return diag.Errorf("Error creating Dns: %s", err)
+		return apiErrorf("Error creating Dns: %s", err)
 	}
 
 	d.SetId(newDns.ID)
-	log.Printf("[INFO] Dns created, ID: %s", d.Id())
+	logInfo(ctx, subsystemDns, "Dns created", map[string]interface{}{"id": d.Id()})
 
 	return resourceRustackDnsRead(ctx, d, meta)
 }
 
 func resourceRustackDnsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	Dns, err := manager.GetDns(d.Id())
 	if err != nil {
-		if err.(*rustack.RustackApiError).Code() == 404 {
+		if isNotFound(err) {
 			d.SetId("")
 			return nil
 		} else {
-			return diag.Errorf("id: Error getting Dns: %s", err)
+			return apiErrorf("id: Error getting Dns: %s", err)
 		}
 	}
 
 	d.SetId(Dns.ID)
 	d.Set("name", Dns.Name)
-	d.Set("project", Dns.Project.ID)
+	d.Set("project_id", Dns.Project.ID)
 	d.Set("tags", marshalTagNames(Dns.Tags))
 
 	return nil
 }
 
 func resourceRustackDnsDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	dns, err := manager.GetDns(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Error getting Dns: %s", err)
+		return apiErrorf("id: Error getting Dns: %s", err)
 	}
 
 	err = dns.Delete()
 	if err != nil {
-		return diag.Errorf("Error deleting Dns: %s", err)
+		return apiErrorf("Error deleting Dns: %s", err)
 	}
 
 	return nil
@@ -2,7 +2,6 @@ package rustack_terraform
 
 import (
 	"context"
-	"log"
 	"strings"
 	"time"
 
@@ -26,6 +25,8 @@ func resourceRustackDnsRecord() *schema.Resource {
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
 			Delete: schema.DefaultTimeout(10 * time.Minute),
 		},
 		Schema: args,
@@ -33,11 +34,11 @@ func resourceRustackDnsRecord() *schema.Resource {
 }
 
 func resourceRustackDnsRecordCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	dns_id := d.Get("dns_id").(string)
 	dns, err := manager.GetDns(dns_id)
 	if err != nil {
-		return diag.Errorf("vdc_id: Error getting Dns: %s", err)
+		return apiErrorf("vdc_id: Error getting Dns: %s", err)
 	}
 
 	host := d.Get("host").(string)
@@ -59,25 +60,25 @@ func resourceRustackDnsRecordCreate(ctx context.Context, d *schema.ResourceData,
 
 	err = dns.CreateDnsRecord(&newDnsRecord)
 	if err != nil {
-		return diag.Errorf("Error creating Dns record: %s", err)
+		return apiErrorf("Error creating Dns record: %s", err)
 	}
 
 	d.SetId(newDnsRecord.ID)
-	log.Printf("[INFO] Dns record created, ID: %s", d.Id())
+	logInfo(ctx, subsystemDns, "Dns record created", map[string]interface{}{"id": d.Id()})
 
 	return resourceRustackDnsRecordRead(ctx, d, meta)
 }
 
 func resourceRustackDnsRecordUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	dns_id := d.Get("dns_id").(string)
 	dns, err := manager.GetDns(dns_id)
 	if err != nil {
-		return diag.Errorf("id: Error getting Dns: %s", err)
+		return apiErrorf("id: Error getting Dns: %s", err)
 	}
 	dnsRecord, err := dns.GetDnsRecord(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Error getting Dns record: %s", err)
+		return apiErrorf("id: Error getting Dns record: %s", err)
 	}
 
 	if d.HasChange("data") {
@@ -109,27 +110,32 @@ func resourceRustackDnsRecordUpdate(ctx context.Context, d *schema.ResourceData,
 	}
 
 	if err = dnsRecord.Update(); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 
 	return resourceRustackDnsRecordRead(ctx, d, meta)
 }
 
 func resourceRustackDnsRecordRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	dns_id := d.Get("dns_id").(string)
 	dns, err := manager.GetDns(dns_id)
 	if err != nil {
-		return diag.Errorf("id: Error getting Dns: %s", err)
+		// the record is removed together with its dns zone
+		if isNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return apiErrorf("id: Error getting Dns: %s", err)
 	}
 	dns_record_id := d.Id()
 	dnsRecord, err := dns.GetDnsRecord(dns_record_id)
 	if err != nil {
-		if err.(*rustack.RustackApiError).Code() == 404 {
+		if isNotFound(err) {
 			d.SetId("")
 			return nil
 		} else {
-			return diag.Errorf("id: Error getting Dns record: %s", err)
+			return apiErrorf("id: Error getting Dns record: %s", err)
 		}
 	}
 
@@ -148,20 +154,20 @@ func resourceRustackDnsRecordRead(ctx context.Context, d *schema.ResourceData, m
 }
 
 func resourceRustackDnsRecordDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	dns_id := d.Get("dns_id").(string)
 	dns, err := manager.GetDns(dns_id)
 	if err != nil {
-		return diag.Errorf("id: Error getting Dns: %s", err)
+		return apiErrorf("id: Error getting Dns: %s", err)
 	}
 	dnsRecord, err := dns.GetDnsRecord(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Error getting Dns record: %s", err)
+		return apiErrorf("id: Error getting Dns record: %s", err)
 	}
 
 	err = dnsRecord.Delete()
 	if err != nil {
-		return diag.Errorf("Error deleting Dns: %s", err)
+		return apiErrorf("Error deleting Dns: %s", err)
 	}
 
 	return nil
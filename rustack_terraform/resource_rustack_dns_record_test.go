@@ -0,0 +1,54 @@
+package rustack_terraform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccRustackDnsRecord_basic(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_dns_record", "record"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackDnsRecordConfig(api, "192.0.2.10"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckExists(api, "rustack_dns_record.test", "record"),
+					resource.TestCheckResourceAttr("rustack_dns_record.test", "type", "A"),
+					resource.TestCheckResourceAttr("rustack_dns_record.test", "data", "192.0.2.10"),
+				),
+			},
+			{
+				Config: testAccRustackDnsRecordConfig(api, "192.0.2.20"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_dns_record.test", "data", "192.0.2.20"),
+				),
+			},
+		},
+	})
+}
+
+func testAccRustackDnsRecordConfig(api *fakeRustackAPI, data string) string {
+	return api.providerConfig() + fmt.Sprintf(`
+resource "rustack_project" "test" {
+  name = "terraform-acc"
+}
+
+resource "rustack_dns" "test" {
+  name       = "example.com."
+  project_id = rustack_project.test.id
+}
+
+resource "rustack_dns_record" "test" {
+  dns_id = rustack_dns.test.id
+  type   = "A"
+  host   = "www.example.com."
+  data   = %q
+}
+`, data)
+}
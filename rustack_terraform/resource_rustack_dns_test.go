@@ -0,0 +1,52 @@
+package rustack_terraform
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccRustackDns_basic(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_dns", "dns"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackDnsConfig(api, `["acc"]`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckExists(api, "rustack_dns.test", "dns"),
+					resource.TestCheckResourceAttr("rustack_dns.test", "name", "example.com."),
+					resource.TestCheckResourceAttr("rustack_dns.test", "tags.#", "1"),
+				),
+			},
+			{
+				Config: testAccRustackDnsConfig(api, `["acc", "dns"]`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_dns.test", "tags.#", "2"),
+				),
+			},
+			{
+				ResourceName:      "rustack_dns.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccRustackDnsConfig(api *fakeRustackAPI, tags string) string {
+	return api.providerConfig() + `
+resource "rustack_project" "test" {
+  name = "terraform-acc"
+}
+
+resource "rustack_dns" "test" {
+  name       = "example.com."
+  project_id = rustack_project.test.id
+  tags       = ` + tags + `
+}
+`
+}
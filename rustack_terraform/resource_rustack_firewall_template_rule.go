@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"regexp"
 	"time"
 
@@ -28,6 +27,8 @@ func resourceRustackFirewallRule() *schema.Resource {
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
 			Delete: schema.DefaultTimeout(10 * time.Minute),
 		},
 		Schema: args,
@@ -35,11 +36,11 @@ func resourceRustackFirewallRule() *schema.Resource {
 }
 
 func resourceRustackFirewallRuleCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	firewall_id := d.Get("firewall_id").(string)
 	firewall, err := manager.GetFirewallTemplate(firewall_id)
 	if err != nil {
-		return diag.Errorf("firewall_id: Error getting FirewallTemplate: %s", err)
+		return apiErrorf("firewall_id: Error getting FirewallTemplate: %s", err)
 	}
 	protocol := d.Get("protocol").(string)
 	var newFirewallRule rustack.FirewallRule
@@ -50,34 +51,39 @@ func resourceRustackFirewallRuleCreate(ctx context.Context, d *schema.ResourceDa
 	if protocol == "tcp" || protocol == "udp" {
 		err = setUpRule(&newFirewallRule, d)
 		if err != nil {
-			return diag.Errorf("port_range: Error creating FirewallRule: %s", err)
+			return apiErrorf("port_range: Error creating FirewallRule: %s", err)
 		}
 	}
 	if err = firewall.CreateFirewallRule(&newFirewallRule); err != nil {
-		return diag.Errorf("Error creating FirewallRule: %s", err)
+		return apiErrorf("Error creating FirewallRule: %s", err)
 	}
 	d.SetId(newFirewallRule.ID)
-	log.Printf("[INFO] Firewall Rule created, ID: %s", d.Id())
+	logInfo(ctx, subsystemFirewall, "Firewall Rule created", map[string]interface{}{"id": d.Id()})
 	return resourceRustackFirewallRuleRead(ctx, d, meta)
 }
 
 func resourceRustackFirewallRuleRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	firewall_id := d.Get("firewall_id").(string)
 	firewallRule_id := d.Id()
 
 	firewall, err := manager.GetFirewallTemplate(firewall_id)
 	if err != nil {
-		return diag.Errorf("firewall_id: Error getting Firewall Template: %s", err)
+		// the rule is removed together with its firewall template
+		if isNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return apiErrorf("firewall_id: Error getting Firewall Template: %s", err)
 	}
 
 	firewallRule, err := firewall.GetRuleById(firewallRule_id)
 	if err != nil {
-		if err.(*rustack.RustackApiError).Code() == 404 {
+		if isNotFound(err) {
 			d.SetId("")
 			return nil
 		} else {
-			return diag.Errorf("id: Error getting fierwall Rule: %s", err)
+			return apiErrorf("id: Error getting fierwall Rule: %s", err)
 		}
 	}
 
@@ -97,18 +103,18 @@ func resourceRustackFirewallRuleRead(ctx context.Context, d *schema.ResourceData
 }
 
 func resourceRustackFirewallRuleUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	firewall_id := d.Get("firewall_id").(string)
 	firewallRule_id := d.Id()
 
 	firewall, err := manager.GetFirewallTemplate(firewall_id)
 	if err != nil {
-		return diag.Errorf("firewall_id: Error getting Firewall Template: %s", err)
+		return apiErrorf("firewall_id: Error getting Firewall Template: %s", err)
 	}
 
 	firewallRule, err := firewall.GetRuleById(firewallRule_id)
 	if err != nil {
-		return diag.Errorf("id: Error getting fierwall Rule: %s", err)
+		return apiErrorf("id: Error getting fierwall Rule: %s", err)
 	}
 
 	firewallRule.Name = d.Get("name").(string)
@@ -118,38 +124,38 @@ func resourceRustackFirewallRuleUpdate(ctx context.Context, d *schema.ResourceDa
 	if protocol == "tcp" || protocol == "udp" {
 		err = setUpRule(firewallRule, d)
 		if err != nil {
-			return diag.Errorf("port_range: Error updating FirewallRule: %s", err)
+			return apiErrorf("port_range: Error updating FirewallRule: %s", err)
 		}
 	}
 	if err = firewallRule.Update(); err != nil {
-		return diag.Errorf("Error updating Fierwall rule: %s", err)
+		return apiErrorf("Error updating Fierwall rule: %s", err)
 	}
 
 	return resourceRustackFirewallRuleRead(ctx, d, meta)
 }
 
 func resourceRustackFirewallRuleDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	firewall_id := d.Get("firewall_id").(string)
 	firewallRule_id := d.Id()
 
 	firewall, err := manager.GetFirewallTemplate(firewall_id)
 	if err != nil {
-		return diag.Errorf("firewall_id: Error getting Firewall Template: %s", err)
+		return apiErrorf("firewall_id: Error getting Firewall Template: %s", err)
 	}
 
 	firewallRule, err := firewall.GetRuleById(firewallRule_id)
 	if err != nil {
-		return diag.Errorf("id: Error getting fierwall Rule: %s", err)
+		return apiErrorf("id: Error getting fierwall Rule: %s", err)
 	}
 
 	err = firewallRule.Delete()
 	if err != nil {
-		return diag.Errorf("Error deleting Fierwall rule: %s", err)
+		return apiErrorf("Error deleting Fierwall rule: %s", err)
 	}
 
 	d.SetId("")
-	log.Printf("[INFO] Fierwall rule deleted, ID: %s", firewallRule_id)
+	logInfo(ctx, subsystemFirewall, "Firewall rule deleted", map[string]interface{}{"id": firewallRule_id})
 	return nil
 }
 
@@ -0,0 +1,53 @@
+package rustack_terraform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccRustackFirewallRule_basic(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_firewall_template_rule", "rule"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackFirewallRuleConfig(api, "80"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckExists(api, "rustack_firewall_template_rule.test", "rule"),
+					resource.TestCheckResourceAttr("rustack_firewall_template_rule.test", "name", "http"),
+					resource.TestCheckResourceAttr("rustack_firewall_template_rule.test", "protocol", "tcp"),
+					resource.TestCheckResourceAttr("rustack_firewall_template_rule.test", "port_range", "80"),
+				),
+			},
+			{
+				Config: testAccRustackFirewallRuleConfig(api, "8000:8080"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_firewall_template_rule.test", "port_range", "8000:8080"),
+				),
+			},
+		},
+	})
+}
+
+func testAccRustackFirewallRuleConfig(api *fakeRustackAPI, portRange string) string {
+	return testAccBaseConfig(api) + fmt.Sprintf(`
+resource "rustack_firewall_template" "test" {
+  vdc_id = rustack_vdc.test.id
+  name   = "terraform-acc"
+}
+
+resource "rustack_firewall_template_rule" "test" {
+  firewall_id    = rustack_firewall_template.test.id
+  name           = "http"
+  direction      = "ingress"
+  protocol       = "tcp"
+  port_range     = %q
+  destination_ip = "0.0.0.0/0"
+}
+`, portRange)
+}
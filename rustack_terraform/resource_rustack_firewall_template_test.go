@@ -0,0 +1,49 @@
+package rustack_terraform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccRustackFirewallTemplate_basic(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_firewall_template", "firewall"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackFirewallTemplateConfig(api, "terraform-acc"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckExists(api, "rustack_firewall_template.test", "firewall"),
+					resource.TestCheckResourceAttr("rustack_firewall_template.test", "name", "terraform-acc"),
+				),
+			},
+			{
+				Config: testAccRustackFirewallTemplateConfig(api, "terraform-acc-renamed"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_firewall_template.test", "name", "terraform-acc-renamed"),
+				),
+			},
+			{
+				ResourceName:            "rustack_firewall_template.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"vdc_id"},
+			},
+		},
+	})
+}
+
+func testAccRustackFirewallTemplateConfig(api *fakeRustackAPI, name string) string {
+	return testAccBaseConfig(api) + fmt.Sprintf(`
+resource "rustack_firewall_template" "test" {
+  vdc_id = rustack_vdc.test.id
+  name   = %q
+  tags   = ["acc"]
+}
+`, name)
+}
@@ -3,10 +3,10 @@ package rustack_terraform
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/rustack-cloud-platform/rcp-go/rustack"
 )
@@ -14,30 +14,42 @@ import (
 func resourceRustackKubernetes() *schema.Resource {
 	args := Defaults()
 	args.injectCreateKubernetes()
-	args.injectContextVdcById()
+	args.injectResultKubeconfig()
+	args.injectContextDefaultVdcById()
 	args.injectContextKubernetesTemplateById() // override template_id
 
 	return &schema.Resource{
-		CreateContext: resourceRustackKubernetesCreate,
+		CreateContext: serializeInVdc(resourceRustackKubernetesCreate),
 		ReadContext:   resourceRustackKubernetesRead,
-		UpdateContext: resourceRustackKubernetesUpdate,
-		DeleteContext: resourceRustackKubernetesDelete,
+		UpdateContext: serializeInVdc(resourceRustackKubernetesUpdate),
+		DeleteContext: serializeInVdc(resourceRustackKubernetesDelete),
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
 			Delete: schema.DefaultTimeout(10 * time.Minute),
 		},
 		Schema: args,
+		CustomizeDiff: customdiff.All(
+			customizeDiffDefaultVdc,
+			customizeDiffKubernetesSizing,
+			customizeDiffTags,
+			// scaling adds or removes node VMs
+			customdiff.ComputedIf("vms", func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) bool {
+				return d.HasChange("nodes_count")
+			}),
+		),
 	}
 }
 
 func resourceRustackKubernetesCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("vdc_id: Error getting VDC: %s", err)
+		return apiErrorf("vdc_id: Error getting VDC: %s", err)
 	}
 
 	platform_id := d.Get("platform").(string)
@@ -46,11 +58,11 @@ func resourceRustackKubernetesCreate(ctx context.Context, d *schema.ResourceData
 	}
 	platform, err := manager.GetPlatform(platform_id)
 	if err != nil {
-		return diag.Errorf("template_id: Error getting template: %s", err)
+		return apiErrorf("platform: Error getting platform: %s", err)
 	}
 	template, err := GetKubernetesTemplateById(d, manager, targetVdc)
 	if err != nil {
-		return diag.Errorf("template_id: Error getting template: %s", err)
+		return apiErrorf("template_id: Error getting template: %s", err)
 	}
 
 	sp_id := d.Get("node_storage_profile_id").(string)
@@ -62,14 +74,19 @@ func resourceRustackKubernetesCreate(ctx context.Context, d *schema.ResourceData
 	userPublicKey := d.Get("user_public_key_id").(string)
 	pub_key, err := manager.GetPublicKey(userPublicKey)
 	if err != nil {
-		return diag.Errorf("storage_profile_id: Error storage profile %s not found", userPublicKey)
+		return diag.Errorf("user_public_key_id: Error public key %s not found", userPublicKey)
 	}
 	name := d.Get("name").(string)
 	cpu := d.Get("node_cpu").(int)
 	ram := d.Get("node_ram").(int)
 	nodesCount := d.Get("nodes_count").(int)
 	nodeDiskSize := d.Get("node_disk_size").(int)
-	log.Printf(name, cpu, ram, template.Name)
+	logDebug(ctx, subsystemKubernetes, "Creating kubernetes cluster", map[string]interface{}{
+		"name":     name,
+		"node_cpu": cpu,
+		"node_ram": ram,
+		"template": template.Name,
+	})
 
 	var floatingIp *string = nil
 	if d.Get("floating").(bool) {
@@ -78,43 +95,56 @@ func resourceRustackKubernetesCreate(ctx context.Context, d *schema.ResourceData
 	}
 
 	newKubernetes := rustack.NewKubernetes(name, cpu, ram, nodesCount, nodeDiskSize, floatingIp, template, storage_profile, pub_key.ID, platform)
-	newKubernetes.Tags = unmarshalTagNames(d.Get("tags"))
+	newKubernetes.Tags = expandTags(d, meta)
 
 	err = targetVdc.CreateKubernetes(&newKubernetes)
 	if err != nil {
-		return diag.Errorf("Error creating Kubernetes: %s", err)
+		return apiErrorf("Error creating Kubernetes: %s", err)
 	}
 
-	newKubernetes.WaitLock()
+	if err := waitLock(ctx, manager, &newKubernetes); err != nil {
+		return apiErrorDiag(err)
+	}
 
 	d.SetId(newKubernetes.ID)
 
-	log.Printf("[INFO] Kubernetes created, ID: %s", d.Id())
+	logInfo(ctx, subsystemKubernetes, "Kubernetes created", map[string]interface{}{"id": d.Id()})
 
 	return resourceRustackKubernetesRead(ctx, d, meta)
 }
 
 func resourceRustackKubernetesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diagErr diag.Diagnostics) {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	Kubernetes, err := manager.GetKubernetes(d.Id())
 	if err != nil {
-		if err.(*rustack.RustackApiError).Code() == 404 {
+		if isNotFound(err) {
 			d.SetId("")
 			return nil
 		} else {
-			return diag.Errorf("id: Error getting Kubernetes: %s", err)
+			return apiErrorf("id: Error getting Kubernetes: %s", err)
 		}
 	}
 
 	d.SetId(Kubernetes.ID)
 	d.Set("name", Kubernetes.Name)
+	if Kubernetes.Vdc != nil {
+		d.Set("vdc_id", Kubernetes.Vdc.ID)
+	}
 	d.Set("node_cpu", Kubernetes.NodeCpu)
 	d.Set("node_ram", Kubernetes.NodeRam)
 	d.Set("nodes_count", Kubernetes.NodesCount)
 	d.Set("node_disk_size", Kubernetes.NodeDiskSize)
-	d.Set("platform", Kubernetes.NodePlatform.ID)
+	d.Set("user_public_key_id", Kubernetes.UserPublicKey)
+	if Kubernetes.NodeStorageProfile != nil {
+		d.Set("node_storage_profile_id", Kubernetes.NodeStorageProfile.ID)
+	}
+	if Kubernetes.NodePlatform != nil {
+		d.Set("platform", Kubernetes.NodePlatform.ID)
+	}
 	d.Set("template_id", Kubernetes.Template.ID)
-	d.Set("tags", marshalTagNames(Kubernetes.Tags))
+	if err := setTags(d, meta, Kubernetes.Tags); err != nil {
+		return apiErrorDiag(err)
+	}
 
 	vms := make([]*string, len(Kubernetes.Vms))
 	for i, vm := range Kubernetes.Vms {
@@ -128,15 +158,24 @@ func resourceRustackKubernetesRead(ctx context.Context, d *schema.ResourceData,
 		d.Set("floating_ip", Kubernetes.Floating.IpAddress)
 	}
 
-	err = Kubernetes.GetKubernetesConfigUrl()
+	config, err := getKubeconfig(ctx, manager, Kubernetes.ID)
 	if err != nil {
-		diagErr = diag.Errorf("config: Error getting Kubernetes config: %s", err)
+		diagErr = apiErrorf("kubeconfig: Error getting Kubernetes config: %s", err)
+		return
+	}
+	flatConfig, err := flattenKubeconfig(config)
+	if err != nil {
+		diagErr = diag.Errorf("kubeconfig: %s", err)
+		return
+	}
+	if err := setResourceDataFromMap(d, flatConfig); err != nil {
+		diagErr = diag.FromErr(err)
 		return
 	}
 
 	dashboard, err := Kubernetes.GetKubernetesDashBoardUrl()
 	if err != nil {
-		diagErr = diag.Errorf("dashboard_url: Error getting Kubernetes dashboard url: %s", err)
+		diagErr = apiErrorf("dashboard_url: Error getting Kubernetes dashboard url: %s", err)
 		return
 	}
 	dashboard_url := fmt.Sprint(manager.BaseURL, *dashboard.DashBoardUrl)
@@ -146,78 +185,103 @@ func resourceRustackKubernetesRead(ctx context.Context, d *schema.ResourceData,
 }
 
 func resourceRustackKubernetesUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
-	targetVdc, err := GetVdcById(d, manager)
-	if err != nil {
-		return diag.Errorf("vdc_id: Error getting VDC: %s", err)
-	}
-
-	needUpdate := false
-
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	kubernetes, err := manager.GetKubernetes(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Error getting Kubernetes: %s", err)
+		return apiErrorf("id: Error getting Kubernetes: %s", err)
 	}
 
-	// Detect Kubernetes changes
-	if d.HasChange("name") {
-		needUpdate = true
+	// Each operation below is a separate task of the API. When one of them
+	// fails the previous state is kept, so the next apply retries it.
+	d.Partial(true)
+
+	if d.HasChanges("name", "floating", "tags_all") {
 		kubernetes.Name = d.Get("name").(string)
-	}
-	if d.HasChange("tags") {
-		needUpdate = true
-		kubernetes.Tags = unmarshalTagNames(d.Get("tags"))
-	}
-	needUpdate = true
-	sp_id := d.Get("node_storage_profile_id").(string)
-	storage_profile, err := targetVdc.GetStorageProfile(sp_id)
-	if err != nil {
-		return diag.Errorf("storage_profile_id: Error storage profile %s not found", sp_id)
+		kubernetes.Tags = expandTags(d, meta)
+		if d.HasChange("floating") {
+			if !d.Get("floating").(bool) {
+				kubernetes.Floating = &rustack.Port{IpAddress: nil}
+			} else {
+				kubernetes.Floating = &rustack.Port{ID: "RANDOM_FIP"}
+			}
+		}
+		logDebug(ctx, subsystemKubernetes, "Updating kubernetes cluster", map[string]interface{}{"id": d.Id()})
+		if err := updateKubernetes(ctx, manager, kubernetes); err != nil {
+			return apiErrorf("Error updating Kubernetes: %s", err)
+		}
 	}
 
-	userPublicKey := d.Get("user_public_key_id").(string)
-	pub_key, err := manager.GetPublicKey(userPublicKey)
-	if err != nil {
-		return diag.Errorf("storage_profile_id: Error storage profile %s not found", userPublicKey)
-	}
-	kubernetes.NodeRam = d.Get("node_cpu").(int)
-	kubernetes.NodeCpu = d.Get("node_ram").(int)
-	kubernetes.UserPublicKey = pub_key.ID
-	kubernetes.NodeStorageProfile = storage_profile
-	kubernetes.NodeDiskSize = d.Get("node_disk_size").(int)
-	kubernetes.NodesCount = d.Get("nodes_count").(int)
-
-	if d.HasChange("floating") {
-		needUpdate = true
-		if !d.Get("floating").(bool) {
-			kubernetes.Floating = &rustack.Port{IpAddress: nil}
-		} else {
-			kubernetes.Floating = &rustack.Port{ID: "RANDOM_FIP"}
+	if d.HasChanges("node_cpu", "node_ram", "node_disk_size", "node_storage_profile_id", "user_public_key_id") {
+		targetVdc, err := GetVdcById(d, manager)
+		if err != nil {
+			return apiErrorf("vdc_id: Error getting VDC: %s", err)
+		}
+		sp_id := d.Get("node_storage_profile_id").(string)
+		storage_profile, err := targetVdc.GetStorageProfile(sp_id)
+		if err != nil {
+			return diag.Errorf("node_storage_profile_id: Error storage profile %s not found", sp_id)
+		}
+		userPublicKey := d.Get("user_public_key_id").(string)
+		pub_key, err := manager.GetPublicKey(userPublicKey)
+		if err != nil {
+			return diag.Errorf("user_public_key_id: Error public key %s not found", userPublicKey)
+		}
+
+		kubernetes.NodeCpu = d.Get("node_cpu").(int)
+		kubernetes.NodeRam = d.Get("node_ram").(int)
+		kubernetes.NodeDiskSize = d.Get("node_disk_size").(int)
+		kubernetes.NodeStorageProfile = storage_profile
+		kubernetes.UserPublicKey = pub_key.ID
+		logDebug(ctx, subsystemKubernetes, "Updating kubernetes nodes", map[string]interface{}{
+			"id":       d.Id(),
+			"node_cpu": kubernetes.NodeCpu,
+			"node_ram": kubernetes.NodeRam,
+		})
+		if err := updateKubernetes(ctx, manager, kubernetes); err != nil {
+			return apiErrorf("Error updating Kubernetes nodes: %s", err)
 		}
-		d.Set("floating", kubernetes.Floating != nil)
 	}
 
-	if needUpdate {
-		if err := repeatOnError(kubernetes.Update, kubernetes); err != nil {
-			return diag.Errorf("Error updating Kubernetes: %s", err)
+	if d.HasChange("nodes_count") {
+		oldCount, newCount := d.GetChange("nodes_count")
+		kubernetes.NodesCount = newCount.(int)
+		logDebug(ctx, subsystemKubernetes, "Scaling kubernetes cluster", map[string]interface{}{
+			"id":   d.Id(),
+			"from": oldCount,
+			"to":   newCount,
+		})
+		if err := updateKubernetes(ctx, manager, kubernetes); err != nil {
+			return apiErrorf("nodes_count: Error scaling Kubernetes from %d to %d nodes: %s", oldCount, newCount, err)
 		}
 	}
 
+	d.Partial(false)
 	return resourceRustackKubernetesRead(ctx, d, meta)
 }
 
+// updateKubernetes sends the cluster to the API once it is unlocked and
+// waits for the task started by the change.
+func updateKubernetes(ctx context.Context, manager *rustack.Manager, kubernetes *rustack.Kubernetes) error {
+	if err := runUnlocked(ctx, manager, kubernetes.Update, kubernetes); err != nil {
+		return err
+	}
+	return waitLock(ctx, manager, kubernetes)
+}
+
 func resourceRustackKubernetesDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	kubernetes, err := manager.GetKubernetes(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Error getting Kubernetes: %s", err)
+		return apiErrorf("id: Error getting Kubernetes: %s", err)
 	}
 
 	err = kubernetes.Delete()
 	if err != nil {
-		return diag.Errorf("Error deleting Kubernetes: %s", err)
+		return apiErrorf("Error deleting Kubernetes: %s", err)
+	}
+	if err := waitLock(ctx, manager, kubernetes); err != nil {
+		return apiErrorDiag(err)
 	}
-	kubernetes.WaitLock()
 
 	return nil
 }
@@ -2,36 +2,44 @@ package rustack_terraform
 
 import (
 	"context"
-	"log"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/rustack-cloud-platform/rcp-go/rustack"
 )
 
 func resourceRustackLbaas() *schema.Resource {
 	args := Defaults()
-	args.injectContextVdcById()
+	args.injectContextDefaultVdcById()
 	args.injectCreateLbaas()
 
 	return &schema.Resource{
-		CreateContext: resourceRustackLbaasCreate,
+		CreateContext: serializeInVdc(resourceRustackLbaasCreate),
 		ReadContext:   resourceRustackLbaasRead,
-		UpdateContext: resourceRustackLbaasUpdate,
-		DeleteContext: resourceRustackLbaasDelete,
+		UpdateContext: serializeInVdc(resourceRustackLbaasUpdate),
+		DeleteContext: serializeInVdc(resourceRustackLbaasDelete),
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
-		Schema: args,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+		Schema:        args,
+		CustomizeDiff: customdiff.All(customizeDiffDefaultVdc, customizeDiffTags),
 	}
 }
 
 func resourceRustackLbaasCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 
 	vdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("vdc_id: Error getting vdc : %s", err)
+		return apiErrorf("vdc_id: Error getting vdc : %s", err)
 	}
 
 	// create port
@@ -44,9 +52,11 @@ func resourceRustackLbaasCreate(ctx context.Context, d *schema.ResourceData, met
 
 	network, err := manager.GetNetwork(lbaasPort["network_id"].(string))
 	if err != nil {
-		return diag.Errorf("network_id: Error getting network by id: %s", err)
+		return apiErrorf("network_id: Error getting network by id: %s", err)
+	}
+	if err := waitLock(ctx, manager, network); err != nil {
+		return apiErrorDiag(err)
 	}
-	network.WaitLock()
 	firewalls := make([]*rustack.FirewallTemplate, 0)
 	ipAddressStr := d.Get(MakePrefix(&portPrefix, "ip_address")).(string)
 	if ipAddressStr == "" {
@@ -55,26 +65,28 @@ func resourceRustackLbaasCreate(ctx context.Context, d *schema.ResourceData, met
 	port := rustack.NewPort(network, firewalls, ipAddressStr)
 
 	newLbaas := rustack.NewLoadBalancer(d.Get("name").(string), vdc, &port, floatingIp)
-	newLbaas.Tags = unmarshalTagNames(d.Get("tags"))
+	newLbaas.Tags = expandTags(d, meta)
 
 	err = vdc.Create(&newLbaas)
 	if err != nil {
-		return diag.Errorf("Error creating Lbaas: %s", err)
+		return apiErrorf("Error creating Lbaas: %s", err)
+	}
+	if err := waitLock(ctx, manager, &newLbaas); err != nil {
+		return apiErrorDiag(err)
 	}
-	newLbaas.WaitLock()
 	d.SetId(newLbaas.ID)
 	return resourceRustackLbaasRead(ctx, d, meta)
 }
 
 func resourceRustackLbaasRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diagErr diag.Diagnostics) {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	lbaas, err := manager.GetLoadBalancer(d.Id())
 	if err != nil {
-		if err.(*rustack.RustackApiError).Code() == 404 {
+		if isNotFound(err) {
 			d.SetId("")
 			return nil
 		} else {
-			return diag.Errorf("id: Error getting Lbaas: %s", err)
+			return apiErrorf("id: Error getting Lbaas: %s", err)
 		}
 	}
 	d.SetId(lbaas.ID)
@@ -91,16 +103,18 @@ func resourceRustackLbaasRead(ctx context.Context, d *schema.ResourceData, meta
 	}
 	d.Set("port", lbaasPort)
 	d.Set("vdc_id", lbaas.Vdc.ID)
-	d.Set("tags", marshalTagNames(lbaas.Tags))
+	if err := setTags(d, meta, lbaas.Tags); err != nil {
+		return apiErrorDiag(err)
+	}
 
 	return
 }
 
 func resourceRustackLbaasUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	lbaas, err := manager.GetLoadBalancer(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Error getting Lbaas: %s", err)
+		return apiErrorf("id: Error getting Lbaas: %s", err)
 	}
 	if d.HasChange("name") {
 		lbaas.Name = d.Get("name").(string)
@@ -113,39 +127,43 @@ func resourceRustackLbaasUpdate(ctx context.Context, d *schema.ResourceData, met
 		}
 		d.Set("floating", lbaas.Floating != nil)
 	}
-	if d.HasChange("tags") {
-		lbaas.Tags = unmarshalTagNames(d.Get("tags"))
+	if d.HasChange("tags_all") {
+		lbaas.Tags = expandTags(d, meta)
 	}
 	lbaasPort := d.Get("port.0").(map[string]interface{})
 	ip_address := lbaasPort["ip_address"].(string)
 	if ip_address != *lbaas.Port.IpAddress {
 		lbaas.Port.IpAddress = &ip_address
 	}
-	if err := repeatOnError(lbaas.Update, lbaas); err != nil {
-		return diag.Errorf("Error updating lbaas: %s", err)
+	if err := runUnlocked(ctx, manager, lbaas.Update, lbaas); err != nil {
+		return apiErrorf("Error updating lbaas: %s", err)
+	}
+	if err := waitLock(ctx, manager, lbaas); err != nil {
+		return apiErrorDiag(err)
 	}
-	lbaas.WaitLock()
 
 	return resourceRustackLbaasRead(ctx, d, meta)
 }
 
 func resourceRustackLbaasDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	lbaasId := d.Id()
 
 	lbaas, err := manager.GetLoadBalancer(lbaasId)
 	if err != nil {
-		return diag.Errorf("id: Error getting Lbaas: %s", err)
+		return apiErrorf("id: Error getting Lbaas: %s", err)
 	}
 
 	lbaas.Delete()
 	if err != nil {
-		return diag.Errorf("Error deleting Lbaas: %s", err)
+		return apiErrorf("Error deleting Lbaas: %s", err)
+	}
+	if err := waitLock(ctx, manager, lbaas); err != nil {
+		return apiErrorDiag(err)
 	}
-	lbaas.WaitLock()
 
 	d.SetId("")
-	log.Printf("[INFO] Lbaas deleted, ID: %s", lbaasId)
+	logInfo(ctx, subsystemLbaas, "Lbaas deleted", map[string]interface{}{"id": lbaasId})
 
 	return nil
 }
@@ -3,7 +3,7 @@ package rustack_terraform
 import (
 	"context"
 	"fmt"
-	"log"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -23,18 +23,24 @@ func resourceRustackLbaasPool() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 		Schema: args,
 	}
 }
 
 func resourceRustackLbaasPoolCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 
 	lbaasId := d.Get("lbaas_id").(string)
 
 	lbaas, err := manager.GetLoadBalancer(lbaasId)
 	if err != nil {
-		return diag.Errorf("id: Error getting Lbaas: %s", err)
+		return apiErrorf("id: Error getting Lbaas: %s", err)
 	}
 	// Get members
 	membersCount := d.Get("member.#").(int)
@@ -49,12 +55,12 @@ func resourceRustackLbaasPoolCreate(ctx context.Context, d *schema.ResourceData,
 
 		vm, err := manager.GetVm(vm_id)
 		if err != nil {
-			return diag.Errorf("vm_id: Error getting vm: %s", err)
+			return apiErrorf("vm_id: Error getting vm: %s", err)
 		}
 
 		newMember := rustack.NewLoadBalancerPoolMember(port, weight, vm)
 		if err != nil {
-			return diag.FromErr(err)
+			return apiErrorDiag(err)
 		}
 		members[i] = &newMember
 	}
@@ -70,30 +76,37 @@ func resourceRustackLbaasPoolCreate(ctx context.Context, d *schema.ResourceData,
 	)
 	err = lbaas.CreatePool(&newPool)
 	if err != nil {
-		return diag.Errorf("id: Error creating Lbaas pool: %s", err)
+		return apiErrorf("id: Error creating Lbaas pool: %s", err)
+	}
+	if err := waitLock(ctx, manager, lbaas); err != nil {
+		return apiErrorDiag(err)
 	}
-	lbaas.WaitLock()
 	d.SetId(newPool.ID)
 	return resourceRustackLbaasPoolRead(ctx, d, meta)
 }
 
 func resourceRustackLbaasPoolRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diagErr diag.Diagnostics) {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	lbaasPoolId := d.Id()
 	lbaasId := d.Get("lbaas_id").(string)
 
 	lbaas, err := manager.GetLoadBalancer(lbaasId)
 	if err != nil {
-		return diag.Errorf("id: Error getting Lbaas: %s", err)
+		// the pool is removed together with its load balancer
+		if isNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return apiErrorf("id: Error getting Lbaas: %s", err)
 	}
 
 	pool, err := lbaas.GetLoadBalancerPool(lbaasPoolId)
 	if err != nil {
-		if err.(*rustack.RustackApiError).Code() == 404 {
+		if isNotFound(err) {
 			d.SetId("")
 			return nil
 		} else {
-			return diag.Errorf("Error getting LbaasPool: %s", err)
+			return apiErrorf("Error getting LbaasPool: %s", err)
 		}
 	}
 
@@ -118,18 +131,23 @@ func resourceRustackLbaasPoolRead(ctx context.Context, d *schema.ResourceData, m
 }
 
 func resourceRustackLbaasPoolUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	lbaasPoolId := d.Id()
 	lbaasId := d.Get("lbaas_id").(string)
 
 	lbaas, err := manager.GetLoadBalancer(lbaasId)
 	if err != nil {
-		return diag.Errorf("id: Error getting Lbaas: %s", err)
+		// the pool is removed together with its load balancer
+		if isNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return apiErrorf("id: Error getting Lbaas: %s", err)
 	}
 
 	pool, err := lbaas.GetLoadBalancerPool(lbaasPoolId)
 	if err != nil {
-		return diag.Errorf("Error getting LbaasPool: %s", err)
+		return apiErrorf("Error getting LbaasPool: %s", err)
 	}
 
 	if d.HasChange("port") {
@@ -159,12 +177,12 @@ func resourceRustackLbaasPoolUpdate(ctx context.Context, d *schema.ResourceData,
 
 			vm, err := manager.GetVm(vm_id)
 			if err != nil {
-				return diag.Errorf("vm_id: Error getting vm: %s", err)
+				return apiErrorf("vm_id: Error getting vm: %s", err)
 			}
 
 			newMember := rustack.NewLoadBalancerPoolMember(port, weight, vm)
 			if err != nil {
-				return diag.FromErr(err)
+				return apiErrorDiag(err)
 			}
 			members[i] = &newMember
 		}
@@ -172,36 +190,40 @@ func resourceRustackLbaasPoolUpdate(ctx context.Context, d *schema.ResourceData,
 	}
 	err = lbaas.UpdatePool(&pool)
 	if err != nil {
-		return diag.Errorf("Error updating Lbaas pool: %s", err)
+		return apiErrorf("Error updating Lbaas pool: %s", err)
+	}
+	if err := waitLock(ctx, manager, lbaas); err != nil {
+		return apiErrorDiag(err)
 	}
-	lbaas.WaitLock()
 
 	return resourceRustackLbaasPoolRead(ctx, d, meta)
 }
 
 func resourceRustackLbaasPoolDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	lbaasPoolId := d.Id()
 	lbaasId := d.Get("lbaas_id").(string)
 
 	lbaas, err := manager.GetLoadBalancer(lbaasId)
 	if err != nil {
-		return diag.Errorf("id: Error getting Lbaas: %s", err)
+		return apiErrorf("id: Error getting Lbaas: %s", err)
 	}
 
 	_, err = lbaas.GetLoadBalancerPool(lbaasPoolId)
 	if err != nil {
-		return diag.Errorf("Error getting LbaasPool: %s", err)
+		return apiErrorf("Error getting LbaasPool: %s", err)
 	}
 
 	lbaas.DeletePool(lbaasPoolId)
 	if err != nil {
-		return diag.Errorf("Error deleting LbaasPool: %s", err)
+		return apiErrorf("Error deleting LbaasPool: %s", err)
+	}
+	if err := waitLock(ctx, manager, lbaas); err != nil {
+		return apiErrorDiag(err)
 	}
-	lbaas.WaitLock()
 
 	d.SetId("")
-	log.Printf("[INFO] LbaasPool deleted, ID: %s", lbaasId)
+	logInfo(ctx, subsystemLbaas, "LbaasPool deleted", map[string]interface{}{"id": lbaasId})
 
 	return nil
 }
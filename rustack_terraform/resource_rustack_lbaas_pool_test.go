@@ -0,0 +1,85 @@
+package rustack_terraform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccRustackLbaasPool_basic(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_lbaas_pool", "pool"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackLbaasPoolConfig(api, 80),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckExists(api, "rustack_lbaas_pool.test", "pool"),
+					resource.TestCheckResourceAttr("rustack_lbaas_pool.test", "port", "80"),
+					resource.TestCheckResourceAttr("rustack_lbaas_pool.test", "member.#", "1"),
+					resource.TestCheckResourceAttrPair("rustack_lbaas_pool.test", "member.0.vm_id", "rustack_vm.test", "id"),
+				),
+			},
+			{
+				Config: testAccRustackLbaasPoolConfig(api, 8080),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_lbaas_pool.test", "port", "8080"),
+				),
+			},
+		},
+	})
+}
+
+func testAccRustackLbaasPoolConfig(api *fakeRustackAPI, port int) string {
+	return testAccBaseConfig(api) + fmt.Sprintf(`
+resource "rustack_port" "test" {
+  vdc_id     = rustack_vdc.test.id
+  network_id = rustack_vdc.test.default_network_id
+}
+
+resource "rustack_vm" "test" {
+  vdc_id      = rustack_vdc.test.id
+  name        = "terraform-acc"
+  cpu         = 1
+  ram         = 1
+  template_id = %[1]q
+  user_data   = "#cloud-config"
+  floating    = false
+
+  system_disk {
+    size               = 10
+    storage_profile_id = %[2]q
+  }
+
+  networks {
+    id = rustack_port.test.id
+  }
+}
+
+resource "rustack_lbaas" "test" {
+  vdc_id = rustack_vdc.test.id
+  name   = "terraform-acc"
+
+  port {
+    network_id = rustack_vdc.test.default_network_id
+  }
+}
+
+resource "rustack_lbaas_pool" "test" {
+  lbaas_id = rustack_lbaas.test.id
+  port     = %[3]d
+  method   = "ROUND_ROBIN"
+  protocol = "TCP"
+
+  member {
+    port   = 80
+    weight = 50
+    vm_id  = rustack_vm.test.id
+  }
+}
+`, api.TemplateID, api.StorageProfileID, port)
+}
@@ -0,0 +1,50 @@
+package rustack_terraform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccRustackLbaas_basic(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_lbaas", "lbaas"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackLbaasConfig(api, "terraform-acc", false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckExists(api, "rustack_lbaas.test", "lbaas"),
+					resource.TestCheckResourceAttr("rustack_lbaas.test", "name", "terraform-acc"),
+					resource.TestCheckResourceAttr("rustack_lbaas.test", "floating", "false"),
+				),
+			},
+			{
+				Config: testAccRustackLbaasConfig(api, "terraform-acc-renamed", true),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_lbaas.test", "name", "terraform-acc-renamed"),
+					resource.TestCheckResourceAttr("rustack_lbaas.test", "floating", "true"),
+					resource.TestCheckResourceAttrSet("rustack_lbaas.test", "floating_ip"),
+				),
+			},
+		},
+	})
+}
+
+func testAccRustackLbaasConfig(api *fakeRustackAPI, name string, floating bool) string {
+	return testAccBaseConfig(api) + fmt.Sprintf(`
+resource "rustack_lbaas" "test" {
+  vdc_id   = rustack_vdc.test.id
+  name     = %q
+  floating = %t
+
+  port {
+    network_id = rustack_vdc.test.default_network_id
+  }
+}
+`, name, floating)
+}
@@ -2,82 +2,95 @@ package rustack_terraform
 
 import (
 	"context"
-	"log"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/rustack-cloud-platform/rcp-go/rustack"
 )
 
 func resourceRustackNetwork() *schema.Resource {
 	args := Defaults()
-	args.injectContextVdcById()
+	args.injectContextDefaultVdcById()
 	args.injectCreateNetwork()
 
 	return &schema.Resource{
-		CreateContext: resourceRustackNetworkCreate,
+		CreateContext: serializeInVdc(resourceRustackNetworkCreate),
 		ReadContext:   resourceRustackNetworkRead,
-		UpdateContext: resourceRustackNetworkUpdate,
-		DeleteContext: resourceRustackNetworkDelete,
+		UpdateContext: serializeInVdc(resourceRustackNetworkUpdate),
+		DeleteContext: serializeInVdc(resourceRustackNetworkDelete),
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
-		Schema: args,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+		Schema:        args,
+		CustomizeDiff: customdiff.All(customizeDiffDefaultVdc, customizeDiffTags),
 	}
 }
 
 func resourceRustackNetworkCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("vdc_id: Error getting VDC: %s", err)
+		return apiErrorf("vdc_id: Error getting VDC: %s", err)
 	}
 
-	log.Printf("[DEBUG] subnetInfo: %#v", targetVdc)
 	network := rustack.NewNetwork(d.Get("name").(string))
-	network.Tags = unmarshalTagNames(d.Get("tags"))
+	network.Tags = expandTags(d, meta)
 	if mtu, ok := d.GetOk("mtu"); ok {
 		mtuValue := mtu.(int)
 		network.Mtu = &mtuValue
 	} else {
 		network.Mtu = nil
 	}
-	targetVdc.WaitLock()
+	if err := waitLock(ctx, manager, targetVdc); err != nil {
+		return apiErrorDiag(err)
+	}
 	if err = targetVdc.CreateNetwork(&network); err != nil {
-		return diag.Errorf("Error creating network: %s", err)
+		return apiErrorf("Error creating network: %s", err)
 	}
 	d.SetId(network.ID)
 
-	diag := createSubnet(d, manager)
-	if diag != nil {
-		return diag
+	if diagErr := createSubnet(ctx, d, manager); diagErr != nil {
+		return diagErr
+	}
+	if err := waitLock(ctx, manager, &network); err != nil {
+		return apiErrorDiag(err)
 	}
-	network.WaitLock()
 
-	log.Printf("[INFO] Network created, ID: %s", d.Id())
+	logInfo(ctx, subsystemNetwork, "Network created", map[string]interface{}{"id": d.Id()})
 
 	return resourceRustackNetworkRead(ctx, d, meta)
 }
 
 func resourceRustackNetworkRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	network, err := manager.GetNetwork(d.Id())
 	if err != nil {
-		if err.(*rustack.RustackApiError).Code() == 404 {
+		if isNotFound(err) {
 			d.SetId("")
 			return nil
 		} else {
-			return diag.Errorf("id: Error getting network: %s", err)
+			return apiErrorf("id: Error getting network: %s", err)
 		}
 	}
 
 	d.Set("name", network.Name)
-	d.Set("tags", marshalTagNames(network.Tags))
+	d.Set("vdc_id", network.Vdc.Id)
+	if err := setTags(d, meta, network.Tags); err != nil {
+		return apiErrorDiag(err)
+	}
 	d.Set("mtu", network.Mtu)
 
 	subnets, err := network.GetSubnets()
 	if err != nil {
-		return diag.Errorf("subnets: Error getting subnets: %s", err)
+		return apiErrorf("subnets: Error getting subnets: %s", err)
 	}
 
 	flattenedRecords := make([]map[string]interface{}, len(subnets))
@@ -98,22 +111,22 @@ func resourceRustackNetworkRead(ctx context.Context, d *schema.ResourceData, met
 	}
 
 	if err := d.Set("subnets", flattenedRecords); err != nil {
-		return diag.Errorf("subnets: unable to set `subnet` attribute: %s", err)
+		return apiErrorf("subnets: unable to set `subnet` attribute: %s", err)
 	}
 
 	return nil
 }
 
 func resourceRustackNetworkUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 
 	network, err := manager.GetNetwork(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Error getting network: %s", err)
+		return apiErrorf("id: Error getting network: %s", err)
 	}
 	shouldUpdate := false
-	if d.HasChange("tags") {
-		network.Tags = unmarshalTagNames(d.Get("tags"))
+	if d.HasChange("tags_all") {
+		network.Tags = expandTags(d, meta)
 		shouldUpdate = true
 	}
 
@@ -133,7 +146,7 @@ func resourceRustackNetworkUpdate(ctx context.Context, d *schema.ResourceData, m
 	if shouldUpdate {
 		err := network.Update()
 		if err != nil {
-			return diag.Errorf("name: Error update network: %s", err)
+			return apiErrorf("name: Error update network: %s", err)
 		}
 	}
 
@@ -143,44 +156,50 @@ func resourceRustackNetworkUpdate(ctx context.Context, d *schema.ResourceData, m
 			return diagErr
 		}
 	}
-	network.WaitLock()
+	if err := waitLock(ctx, manager, network); err != nil {
+		return apiErrorDiag(err)
+	}
 
 	return resourceRustackNetworkRead(ctx, d, meta)
 }
 
 func resourceRustackNetworkDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	network, err := manager.GetNetwork(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Error getting network: %s", err)
+		return apiErrorf("id: Error getting network: %s", err)
 
 	}
 
-	if err = repeatOnError(network.Delete, network); err != nil {
-		return diag.Errorf("Error deleting network: %s", err)
+	if err = runUnlocked(ctx, manager, network.Delete, network); err != nil {
+		return apiErrorf("Error deleting network: %s", err)
+	}
+	if err := waitLock(ctx, manager, network); err != nil {
+		return apiErrorDiag(err)
 	}
-	network.WaitLock()
 
 	return nil
 }
 
-func createSubnet(d *schema.ResourceData, manager *rustack.Manager) (diagErr diag.Diagnostics) {
+func createSubnet(ctx context.Context, d *schema.ResourceData, manager *rustack.Manager) (diagErr diag.Diagnostics) {
 	subnets := d.Get("subnets").([]interface{})
-	log.Printf("[DEBUG] subnets: %#v", subnets)
 	network, err := manager.GetNetwork(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Unable to get network: %s", err)
+		return apiErrorf("id: Unable to get network: %s", err)
 	}
 
 	for _, subnetInfo := range subnets {
-		log.Printf("[DEBUG] subnetInfo: %#v", subnetInfo)
 		subnetInfo2 := subnetInfo.(map[string]interface{})
+		logDebug(ctx, subsystemNetwork, "Creating subnet", map[string]interface{}{
+			"network_id": network.ID,
+			"cidr":       subnetInfo2["cidr"],
+		})
 
 		// Create subnet
 		subnet := rustack.NewSubnet(subnetInfo2["cidr"].(string), subnetInfo2["gateway"].(string), subnetInfo2["start_ip"].(string), subnetInfo2["end_ip"].(string), subnetInfo2["dhcp"].(bool))
 
 		if err := network.CreateSubnet(&subnet); err != nil {
-			return diag.Errorf("subnets: Error creating subnet: %s", err)
+			return apiErrorf("subnets: Error creating subnet: %s", err)
 		}
 
 		dnsServersList := subnetInfo2["dns"].([]interface{})
@@ -191,7 +210,7 @@ func createSubnet(d *schema.ResourceData, manager *rustack.Manager) (diagErr dia
 		}
 
 		if err := subnet.UpdateDNSServers(dnsServers); err != nil {
-			return diag.Errorf("dns: Error Update DNS Servers: %s", err)
+			return apiErrorf("dns: Error Update DNS Servers: %s", err)
 		}
 
 	}
@@ -204,11 +223,11 @@ func updateSubnet(d *schema.ResourceData, manager *rustack.Manager) (diagErr dia
 	subnets := d.Get("subnets").([]interface{})
 	network, err := manager.GetNetwork(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Unable to get network: %s", err)
+		return apiErrorf("id: Unable to get network: %s", err)
 	}
 	subnetsRaw, err := network.GetSubnets()
 	if err != nil {
-		return diag.Errorf("subnets: Unable to get subnets: %s", err)
+		return apiErrorf("subnets: Unable to get subnets: %s", err)
 	}
 
 	for _, subnetInfo := range subnets {
@@ -230,10 +249,10 @@ func updateSubnet(d *schema.ResourceData, manager *rustack.Manager) (diagErr dia
 			// create new subnet
 			newSubnet := rustack.NewSubnet(subnetInfo2["cidr"].(string), subnetInfo2["gateway"].(string), subnetInfo2["start_ip"].(string), subnetInfo2["end_ip"].(string), subnetInfo2["dhcp"].(bool))
 			if err := network.CreateSubnet(&newSubnet); err != nil {
-				return diag.Errorf("subnets: Error creating subnet: %s", err)
+				return apiErrorf("subnets: Error creating subnet: %s", err)
 			}
 			if err := subnet.UpdateDNSServers(newDnsServers); err != nil {
-				return diag.Errorf("dns: Error Update DNS Servers: %s", err)
+				return apiErrorf("dns: Error Update DNS Servers: %s", err)
 			}
 		} else {
 			// update preserved subnet
@@ -261,7 +280,7 @@ func updateSubnet(d *schema.ResourceData, manager *rustack.Manager) (diagErr dia
 			}
 			if shouldUpdate {
 				if err := subnet.UpdateDNSServers(subnet.DnsServers); err != nil {
-					return diag.Errorf("error update subnet: %s", err)
+					return apiErrorf("error update subnet: %s", err)
 				}
 			}
 		}
@@ -277,7 +296,7 @@ func updateSubnet(d *schema.ResourceData, manager *rustack.Manager) (diagErr dia
 		if subnetInfo2 == nil {
 			// delete obsolete subnet
 			if err := subnet.Delete(); err != nil {
-				return diag.Errorf("error deleting subnet: %s", err)
+				return apiErrorf("error deleting subnet: %s", err)
 			}
 		}
 	}
@@ -0,0 +1,60 @@
+package rustack_terraform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccRustackNetwork_basic(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_network", "network"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackNetworkConfig(api, "terraform-acc", "10.20.0.100"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckExists(api, "rustack_network.test", "network"),
+					resource.TestCheckResourceAttr("rustack_network.test", "name", "terraform-acc"),
+					resource.TestCheckResourceAttr("rustack_network.test", "subnets.#", "1"),
+					resource.TestCheckResourceAttr("rustack_network.test", "subnets.0.cidr", "10.20.0.0/24"),
+					resource.TestCheckResourceAttrSet("rustack_network.test", "subnets.0.id"),
+				),
+			},
+			{
+				Config: testAccRustackNetworkConfig(api, "terraform-acc-renamed", "10.20.0.200"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_network.test", "name", "terraform-acc-renamed"),
+					resource.TestCheckResourceAttr("rustack_network.test", "subnets.0.end_ip", "10.20.0.200"),
+				),
+			},
+			{
+				ResourceName:      "rustack_network.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccRustackNetworkConfig(api *fakeRustackAPI, name, endIp string) string {
+	return testAccBaseConfig(api) + fmt.Sprintf(`
+resource "rustack_network" "test" {
+  vdc_id = rustack_vdc.test.id
+  name   = %q
+
+  subnets {
+    cidr     = "10.20.0.0/24"
+    dhcp     = true
+    gateway  = "10.20.0.1"
+    start_ip = "10.20.0.2"
+    end_ip   = %q
+    dns      = ["8.8.8.8", "8.8.4.4"]
+  }
+}
+`, name, endIp)
+}
@@ -20,8 +20,10 @@ func resourceRustackPaasService() *schema.Resource {
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
 			Delete: schema.DefaultTimeout(10 * time.Minute),
 		},
+		CustomizeDiff: customizeDiffDefaultProject,
 		Schema: map[string]*schema.Schema{
 			"id": {
 				Type:        schema.TypeString,
@@ -36,9 +38,10 @@ func resourceRustackPaasService() *schema.Resource {
 			},
 			"project_id": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
-				Description: "id of Project",
+				Description: "id of Project. Defaults to the provider default project",
 			},
 			"paas_service_id": {
 				Type:        schema.TypeInt,
@@ -57,14 +60,14 @@ func resourceRustackPaasService() *schema.Resource {
 }
 
 func resourceRustackPaasServiceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diagErr diag.Diagnostics) {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	service, err := manager.GetPaasService(d.Get("id").(string))
 	if err != nil {
-		if err.(*rustack.RustackApiError).Code() == 404 {
+		if isNotFound(err) {
 			d.SetId("")
 			return nil
 		} else {
-			return diag.Errorf("Error getting Paas Service: %s", err)
+			return apiErrorf("Error getting Paas Service: %s", err)
 		}
 	}
 	d.Set("id", service.ID)
@@ -73,19 +76,19 @@ func resourceRustackPaasServiceRead(ctx context.Context, d *schema.ResourceData,
 	d.Set("paas_service_id", service.PaasServiceID)
 	inputsString, err := json.Marshal(service.Inputs)
 	if err != nil {
-		return diag.Errorf("Error marshalling Paas Service inputs: %s", err)
+		return apiErrorf("Error marshalling Paas Service inputs: %s", err)
 	}
-	d.Set("paas_service_inputs", inputsString)
+	d.Set("paas_service_inputs", string(inputsString))
 	d.SetId(service.ID)
 	return nil
 }
 
 func resourceRustackPaasServiceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	var inputs map[string]interface{}
 	inputsString := d.Get("paas_service_inputs").(string)
 	if err := json.Unmarshal([]byte(inputsString), &inputs); err != nil {
-		return diag.Errorf("Error parsing Paas Service inputs: %s", err)
+		return apiErrorf("Error parsing Paas Service inputs: %s", err)
 	}
 	service := &rustack.PaasService{
 		Name: d.Get("name").(string),
@@ -99,17 +102,17 @@ func resourceRustackPaasServiceCreate(ctx context.Context, d *schema.ResourceDat
 		Inputs:        inputs,
 	}
 	if err := manager.CreatePaasService(service); err != nil {
-		return diag.Errorf("Error creating Paas Service: %s", err)
+		return apiErrorf("Error creating Paas Service: %s", err)
 	}
 	d.Set("id", service.ID)
 	return resourceRustackPaasServiceRead(ctx, d, meta)
 }
 
 func resourceRustackPaasServiceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	err := manager.DeletePaasService(d.Get("id").(string))
 	if err != nil {
-		return diag.Errorf("Error deleting Paas Service: %s", err)
+		return apiErrorf("Error deleting Paas Service: %s", err)
 	}
 	return nil
 }
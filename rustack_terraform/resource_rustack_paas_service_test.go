@@ -0,0 +1,47 @@
+package rustack_terraform
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccRustackPaasService_basic(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_paas_service", "paas_service"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackPaasServiceConfig(api),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckExists(api, "rustack_paas_service.test", "paas_service"),
+					resource.TestCheckResourceAttr("rustack_paas_service.test", "name", "terraform-acc"),
+					resource.TestCheckResourceAttr("rustack_paas_service.test", "paas_service_id", "1"),
+				),
+			},
+			{
+				ResourceName:      "rustack_paas_service.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccRustackPaasServiceConfig(api *fakeRustackAPI) string {
+	return api.providerConfig() + `
+resource "rustack_project" "test" {
+  name = "terraform-acc"
+}
+
+resource "rustack_paas_service" "test" {
+  name                = "terraform-acc"
+  project_id          = rustack_project.test.id
+  paas_service_id     = 1
+  paas_service_inputs = jsonencode({ "version" = "15" })
+}
+`
+}
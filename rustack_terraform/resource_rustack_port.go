@@ -2,46 +2,48 @@ package rustack_terraform
 
 import (
 	"context"
-	"log"
 
-	"fmt"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/rustack-cloud-platform/rcp-go/rustack"
 )
 
 func resourceRustackPort() *schema.Resource {
 	args := Defaults()
-	args.injectContextVdcById()
+	args.injectContextDefaultVdcById()
 	args.injectCreatePort()
 
 	return &schema.Resource{
-		CreateContext: resourceRustackPortCreate,
+		CreateContext: serializeInVdc(resourceRustackPortCreate),
 		ReadContext:   resourceRustackPortRead,
-		UpdateContext: resourceRustackPortUpdate,
-		DeleteContext: resourceRustackPortDelete,
+		UpdateContext: serializeInVdc(resourceRustackPortUpdate),
+		DeleteContext: serializeInVdc(resourceRustackPortDelete),
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
 			Delete: schema.DefaultTimeout(10 * time.Minute),
 		},
-		Schema: args,
+		Schema:        args,
+		CustomizeDiff: customdiff.All(customizeDiffDefaultVdc, customizeDiffTags),
 	}
 }
 
 func resourceRustackPortCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("vdc_id: Error getting VDC: %s", err)
+		return apiErrorf("vdc_id: Error getting VDC: %s", err)
 	}
 	portNetwork, err := GetNetworkById(d, manager, nil)
 	if err != nil {
-		return diag.Errorf("Error getting network: %s", err)
+		return apiErrorf("Error getting network: %s", err)
 	}
 
 	firewallsCount := d.Get("firewall_templates.#").(int)
@@ -50,7 +52,7 @@ func resourceRustackPortCreate(ctx context.Context, d *schema.ResourceData, meta
 	for j, firewallId := range firewallsResourceData {
 		portFirewall, err := manager.GetFirewallTemplate(firewallId.(string))
 		if err != nil {
-			return diag.Errorf("firewall_templates: Error getting Firewall Template: %s", err)
+			return apiErrorf("firewall_templates: Error getting Firewall Template: %s", err)
 		}
 		firewalls[j] = portFirewall
 	}
@@ -63,38 +65,44 @@ func resourceRustackPortCreate(ctx context.Context, d *schema.ResourceData, meta
 		ipAddressStr = "0.0.0.0"
 	}
 
-	log.Printf("[DEBUG] subnetInfo: %#v", targetVdc)
 	newPort := rustack.NewPort(portNetwork, firewalls, ipAddressStr)
-	newPort.Tags = unmarshalTagNames(d.Get("tags"))
-	fmt.Println(ipAddressStr)
-	targetVdc.WaitLock()
+	newPort.Tags = expandTags(d, meta)
+	if err := waitLock(ctx, manager, targetVdc); err != nil {
+		return apiErrorDiag(err)
+	}
 	if err = targetVdc.CreateEmptyPort(&newPort); err != nil {
-		return diag.Errorf("Error creating port: %s", err)
+		return apiErrorf("Error creating port: %s", err)
+	}
+	if err := waitLock(ctx, manager, &newPort); err != nil {
+		return apiErrorDiag(err)
 	}
-	newPort.WaitLock()
 	d.SetId(newPort.ID)
-	fmt.Println(ipAddressStr)
-	log.Printf("[INFO] Port created, ID: %s", d.Id())
+	logInfo(ctx, subsystemPort, "Port created", map[string]interface{}{
+		"id":         d.Id(),
+		"ip_address": ipAddressStr,
+	})
 
 	return resourceRustackPortRead(ctx, d, meta)
 }
 
 func resourceRustackPortRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	port, err := manager.GetPort(d.Id())
 	if err != nil {
-		if err.(*rustack.RustackApiError).Code() == 404 {
+		if isNotFound(err) {
 			d.SetId("")
 			return nil
 		} else {
-			return diag.Errorf("id: Error getting port: %s", err)
+			return apiErrorf("id: Error getting port: %s", err)
 		}
 	}
 
 	d.SetId(port.ID)
 	d.Set("ip_address", port.IpAddress)
-	d.Set("network_id", port.Network)
-	d.Set("tags", marshalTagNames(port.Tags))
+	d.Set("network_id", port.Network.ID)
+	if err := setTags(d, meta, port.Tags); err != nil {
+		return apiErrorDiag(err)
+	}
 
 	firewalls := make([]*string, len(port.FirewallTemplates))
 	for i, firewall := range port.FirewallTemplates {
@@ -107,15 +115,15 @@ func resourceRustackPortRead(ctx context.Context, d *schema.ResourceData, meta i
 }
 
 func resourceRustackPortUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 
 	portId := d.Id()
 	port, err := manager.GetPort(portId)
 	if err != nil {
-		return diag.Errorf("id: Error getting port: %s", err)
+		return apiErrorf("id: Error getting port: %s", err)
 	}
-	if d.HasChange("tags") {
-		port.Tags = unmarshalTagNames(d.Get("tags"))
+	if d.HasChange("tags_all") {
+		port.Tags = expandTags(d, meta)
 	}
 	ip_address := d.Get("ip_address").(string)
 	if d.HasChange("ip_address") {
@@ -129,7 +137,7 @@ func resourceRustackPortUpdate(ctx context.Context, d *schema.ResourceData, meta
 		for j, firewallId := range firewallsResourceData {
 			portFirewall, err := manager.GetFirewallTemplate(firewallId.(string))
 			if err != nil {
-				return diag.Errorf("firewall_templates: Error updating Firewall Template: %s", err)
+				return apiErrorf("firewall_templates: Error updating Firewall Template: %s", err)
 			}
 			firewalls[j] = portFirewall
 		}
@@ -137,28 +145,32 @@ func resourceRustackPortUpdate(ctx context.Context, d *schema.ResourceData, meta
 		port.FirewallTemplates = firewalls
 	}
 	if err := port.Update(); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
+	}
+	if err := waitLock(ctx, manager, port); err != nil {
+		return apiErrorDiag(err)
 	}
-	port.WaitLock()
 	return resourceRustackPortRead(ctx, d, meta)
 }
 
 func resourceRustackPortDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	portId := d.Id()
 
 	port, err := manager.GetPort(portId)
 	if err != nil {
-		return diag.Errorf("id: Error getting port: %s", err)
+		return apiErrorf("id: Error getting port: %s", err)
 	}
 
 	err = port.ForceDelete()
 	if err != nil {
-		return diag.Errorf("Error deleting port: %s", err)
+		return apiErrorf("Error deleting port: %s", err)
+	}
+	if err := waitLock(ctx, manager, port); err != nil {
+		return apiErrorDiag(err)
 	}
-	port.WaitLock()
 
 	d.SetId("")
-	log.Printf("[INFO] Port deleted, ID: %s", portId)
+	logInfo(ctx, subsystemPort, "Port deleted", map[string]interface{}{"id": portId})
 	return nil
 }
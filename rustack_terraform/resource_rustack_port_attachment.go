@@ -0,0 +1,258 @@
+package rustack_terraform
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/rustack-cloud-platform/rcp-go/rustack"
+)
+
+func resourceRustackPortAttachment() *schema.Resource {
+	args := Defaults()
+	args.injectCreatePortAttachment()
+
+	return &schema.Resource{
+		CreateContext: serializeInPortOwner(resourceRustackPortAttachmentCreate),
+		ReadContext:   resourceRustackPortAttachmentRead,
+		UpdateContext: serializeInPortOwner(resourceRustackPortAttachmentUpdate),
+		DeleteContext: serializeInPortOwner(resourceRustackPortAttachmentDelete),
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+		Schema:        args,
+		CustomizeDiff: customizeDiffPortAttachmentOwner,
+	}
+}
+
+// serializeInPortOwner queues changes of an attachment behind the other
+// changes of the vm or router it connects the port to.
+func serializeInPortOwner(f mutationFunc) mutationFunc {
+	return serializeIn("vm_id", "vm", serializeIn("router_id", "router", f))
+}
+
+func resourceRustackPortAttachmentCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
+	port, err := manager.GetPort(d.Get("port_id").(string))
+	if err != nil {
+		return apiErrorf("port_id: Error getting port: %s", err)
+	}
+
+	if diags := connectPort(ctx, d, manager, port, ""); diags.HasError() {
+		return diags
+	}
+
+	// A port is connected to one object at most, so it identifies the attachment
+	d.SetId(port.ID)
+	logInfo(ctx, subsystemPort, "Port attached", map[string]interface{}{"port_id": port.ID, "owner": portAttachmentOwner(d)})
+
+	return resourceRustackPortAttachmentRead(ctx, d, meta)
+}
+
+func resourceRustackPortAttachmentRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
+	port, err := manager.GetPort(d.Id())
+	if err != nil {
+		if isNotFound(err) {
+			d.SetId("")
+			return nil
+		} else {
+			return apiErrorf("id: Error getting port: %s", err)
+		}
+	}
+	if port.Connected == nil {
+		logWarn(ctx, subsystemPort, "Port is no longer connected, removing the attachment from the state", map[string]interface{}{"port_id": port.ID})
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("port_id", port.ID)
+	d.Set("vm_id", "")
+	d.Set("router_id", "")
+	switch port.Connected.Type {
+	case "vm":
+		d.Set("vm_id", port.Connected.ID)
+	case "router":
+		d.Set("router_id", port.Connected.ID)
+	}
+
+	return nil
+}
+
+func resourceRustackPortAttachmentUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
+	if d.HasChanges("vm_id", "router_id") {
+		port, err := manager.GetPort(d.Id())
+		if err != nil {
+			return apiErrorf("id: Error getting port: %s", err)
+		}
+		oldVmID, _ := d.GetChange("vm_id")
+		oldRouterID, _ := d.GetChange("router_id")
+		if diags := connectPort(ctx, d, manager, port, oldVmID.(string)+oldRouterID.(string)); diags.HasError() {
+			return diags
+		}
+		logInfo(ctx, subsystemPort, "Port moved", map[string]interface{}{"port_id": port.ID, "owner": portAttachmentOwner(d)})
+	}
+
+	return resourceRustackPortAttachmentRead(ctx, d, meta)
+}
+
+func resourceRustackPortAttachmentDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
+	port, err := manager.GetPort(d.Id())
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return apiErrorf("id: Error getting port: %s", err)
+	}
+
+	// The port may have been connected elsewhere in the meantime, that
+	// connection is not ours to remove
+	if port.Connected == nil || port.Connected.ID != portAttachmentOwner(d) {
+		return nil
+	}
+	if err := disconnectPort(ctx, manager, port); err != nil {
+		return apiErrorf("Error disconnecting port: %s", err)
+	}
+
+	return nil
+}
+
+// portAttachmentOwner returns the id of the vm or router of the attachment.
+func portAttachmentOwner(d *schema.ResourceData) string {
+	return d.Get("vm_id").(string) + d.Get("router_id").(string)
+}
+
+// customizeDiffPortAttachmentOwner refuses at plan time to connect a port
+// that another vm, router or load balancer owns, e.g. through the networks
+// of a rustack_vm, so that the two resources do not take it from each other
+// on every apply.
+func customizeDiffPortAttachmentOwner(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	c, ok := meta.(*CombinedConfig)
+	if !ok || (d.Id() != "" && !d.HasChanges("vm_id", "router_id")) {
+		return nil
+	}
+	portID, ok := knownString(d, "port_id")
+	if !ok {
+		return nil
+	}
+	port, err := c.rustackManager().WithContext(ctx).GetPort(portID)
+	if err != nil {
+		if isNotFound(err) && d.Id() == "" {
+			return nil
+		}
+		return fmt.Errorf("port_id: Error getting port: %w", err)
+	}
+	if port.Connected == nil {
+		return nil
+	}
+
+	owners := []string{}
+	for _, key := range []string{"vm_id", "router_id"} {
+		if d.Id() != "" {
+			old, _ := d.GetChange(key)
+			owners = append(owners, old.(string))
+		}
+		if target, ok := knownString(d, key); ok {
+			owners = append(owners, target)
+		}
+	}
+	for _, owner := range owners {
+		if owner == port.Connected.ID {
+			return nil
+		}
+	}
+	return portOwnerError("port_id", port)
+}
+
+// portOwnerError reports the port as connected to another owner against the
+// attribute at path.
+func portOwnerError(path string, port *rustack.Port) error {
+	owner := fmt.Sprintf("%s %s", port.Connected.Type, port.Connected.ID)
+	if port.Connected.Name != "" {
+		owner = fmt.Sprintf("%s %q (%s)", port.Connected.Type, port.Connected.Name, port.Connected.ID)
+	}
+	return fmt.Errorf("%s: Port %s is connected to %s, disconnect it there before attaching it", path, port.ID, owner)
+}
+
+// connectPort connects the port to the vm or router of the attachment. A
+// port connected elsewhere is only disconnected first when it is connected
+// to ownedID, the previous owner of the attachment.
+func connectPort(ctx context.Context, d *schema.ResourceData, manager *rustack.Manager, port *rustack.Port, ownedID string) diag.Diagnostics {
+	if port.Connected != nil {
+		if port.Connected.ID == portAttachmentOwner(d) {
+			return nil
+		}
+		if port.Connected.ID != ownedID {
+			return diag.FromErr(portOwnerError("port_id", port))
+		}
+		if err := disconnectPort(ctx, manager, port); err != nil {
+			return apiErrorf("Error disconnecting port from %s %s: %s", port.Connected.Type, port.Connected.ID, err)
+		}
+	}
+
+	if vmID := d.Get("vm_id").(string); vmID != "" {
+		vm, err := manager.GetVm(vmID)
+		if err != nil {
+			return apiErrorf("vm_id: Error getting vm: %s", err)
+		}
+		connect := func() error { return vm.ConnectPort(port, true) }
+		if err := runUnlocked(ctx, manager, connect, vm); err != nil {
+			return apiErrorf("Error connecting port: %s", err)
+		}
+		if err := waitLock(ctx, manager, vm); err != nil {
+			return apiErrorDiag(err)
+		}
+		return nil
+	}
+
+	router, err := manager.GetRouter(d.Get("router_id").(string))
+	if err != nil {
+		return apiErrorf("router_id: Error getting router: %s", err)
+	}
+	connect := func() error { return router.ConnectPort(port, true) }
+	if err := runUnlocked(ctx, manager, connect, router); err != nil {
+		return apiErrorf("Error connecting port: %s", err)
+	}
+	if err := waitLock(ctx, manager, router); err != nil {
+		return apiErrorDiag(err)
+	}
+	return nil
+}
+
+// disconnectPort disconnects the port from its vm or router and waits for
+// it.
+func disconnectPort(ctx context.Context, manager *rustack.Manager, port *rustack.Port) error {
+	switch port.Connected.Type {
+	case "vm":
+		vm, err := manager.GetVm(port.Connected.ID)
+		if err != nil {
+			return err
+		}
+		disconnect := func() error { return vm.DisconnectPort(port) }
+		if err := runUnlocked(ctx, manager, disconnect, vm); err != nil {
+			return err
+		}
+		return waitLock(ctx, manager, vm)
+	case "router":
+		router, err := manager.GetRouter(port.Connected.ID)
+		if err != nil {
+			return err
+		}
+		disconnect := func() error { return router.DisconnectPort(port) }
+		if err := runUnlocked(ctx, manager, disconnect, router); err != nil {
+			return err
+		}
+		return waitLock(ctx, manager, router)
+	}
+	return fmt.Errorf("Port %s of %s %s can not be disconnected", port.ID, port.Connected.Type, port.Connected.ID)
+}
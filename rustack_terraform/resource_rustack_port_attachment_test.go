@@ -0,0 +1,195 @@
+package rustack_terraform
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccRustackPortAttachment_basic(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_port", "port"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackPortAttachmentConfig(api, "", "rustack_vm.a.id"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("rustack_port_attachment.test", "vm_id", "rustack_vm.a", "id"),
+					resource.TestCheckResourceAttrPair("rustack_port_attachment.test", "id", "rustack_port.test", "id"),
+					testAccCheckPortConnectedTo(api, "rustack_port.test", "rustack_vm.a"),
+				),
+			},
+			{
+				Config: testAccRustackPortAttachmentConfig(api, "", "rustack_vm.b.id"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("rustack_port_attachment.test", "vm_id", "rustack_vm.b", "id"),
+					testAccCheckPortConnectedTo(api, "rustack_port.test", "rustack_vm.b"),
+				),
+			},
+			{
+				ResourceName:      "rustack_port_attachment.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccRustackPortAttachment_conflictingOwner(t *testing.T) {
+	api := newFakeRustackAPI(t)
+	owned := `
+  networks {
+    id = rustack_port.test.id
+  }
+`
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_port", "port"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackPortAttachmentConfig(api, owned, ""),
+			},
+			{
+				Config:      testAccRustackPortAttachmentConfig(api, owned, "rustack_vm.b.id"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`port_id: Port .* is connected to vm .*, disconnect it there before attaching it`),
+			},
+		},
+	})
+}
+
+func TestAccRustackPortAttachment_vmNetworks(t *testing.T) {
+	api := newFakeRustackAPI(t)
+	config := testAccRustackPortAttachmentConfig(api, `
+  networks {
+    id = rustack_port.own.id
+  }
+`, "rustack_vm.a.id") + `
+resource "rustack_port" "own" {
+  vdc_id     = rustack_vdc.test.id
+  network_id = rustack_vdc.test.default_network_id
+}
+`
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_port", "port"),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_vm.a", "networks.#", "1"),
+					resource.TestCheckResourceAttrPair("rustack_vm.a", "networks.0.id", "rustack_port.own", "id"),
+					testAccCheckPortConnectedTo(api, "rustack_port.own", "rustack_vm.a"),
+					testAccCheckPortConnectedTo(api, "rustack_port.test", "rustack_vm.a"),
+				),
+			},
+			{
+				Config:   config,
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+func TestAccRustackPortAttachment_router(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_router", "router"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackRouterConfig(api, "terraform-acc") + `
+resource "rustack_port" "attached" {
+  vdc_id     = rustack_vdc.test.id
+  network_id = rustack_vdc.test.default_network_id
+}
+
+resource "rustack_port_attachment" "test" {
+  port_id   = rustack_port.attached.id
+  router_id = rustack_router.test.id
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("rustack_port_attachment.test", "router_id", "rustack_router.test", "id"),
+					resource.TestCheckResourceAttr("rustack_port_attachment.test", "vm_id", ""),
+					testAccCheckPortConnectedTo(api, "rustack_port.attached", "rustack_router.test"),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckPortConnectedTo verifies the object a port is connected to in
+// the fake API.
+func testAccCheckPortConnectedTo(api *fakeRustackAPI, portName, ownerName string) func(*terraform.State) error {
+	return func(s *terraform.State) error {
+		port, ok := s.RootModule().Resources[portName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", portName)
+		}
+		owner, ok := s.RootModule().Resources[ownerName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", ownerName)
+		}
+		connected, _ := api.Object("port", port.Primary.ID)["connected"].(map[string]interface{})
+		if connected == nil || connected["id"] != owner.Primary.ID {
+			return fmt.Errorf("%s is connected to %v in the API, want %s", portName, connected, owner.Primary.ID)
+		}
+		return nil
+	}
+}
+
+// testAccRustackPortAttachmentConfig returns two vms without networks and a
+// port, which vm a owns through networks, and an attachment connecting the
+// port to vmID unless it is empty.
+func testAccRustackPortAttachmentConfig(api *fakeRustackAPI, networks, vmID string) string {
+	config := testAccBaseConfig(api) + `
+resource "rustack_port" "test" {
+  vdc_id     = rustack_vdc.test.id
+  network_id = rustack_vdc.test.default_network_id
+}
+`
+	for _, name := range []string{"a", "b"} {
+		vmNetworks := ""
+		if name == "a" {
+			vmNetworks = networks
+		}
+		config += fmt.Sprintf(`
+resource "rustack_vm" "%[1]s" {
+  vdc_id      = rustack_vdc.test.id
+  name        = "terraform-acc-%[1]s"
+  cpu         = 1
+  ram         = 1
+  template_id = %[2]q
+  user_data   = "#cloud-config"
+  floating    = false
+
+  system_disk {
+    size               = 10
+    storage_profile_id = %[3]q
+  }
+%[4]s}
+`, name, api.TemplateID, api.StorageProfileID, vmNetworks)
+	}
+	if vmID != "" {
+		config += fmt.Sprintf(`
+resource "rustack_port_attachment" "test" {
+  port_id = rustack_port.test.id
+  vm_id   = %s
+}
+`, vmID)
+	}
+	return config
+}
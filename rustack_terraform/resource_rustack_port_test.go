@@ -0,0 +1,52 @@
+package rustack_terraform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccRustackPort_basic(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_port", "port"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackPortConfig(api, "10.0.0.50"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckExists(api, "rustack_port.test", "port"),
+					resource.TestCheckResourceAttr("rustack_port.test", "ip_address", "10.0.0.50"),
+					resource.TestCheckResourceAttr("rustack_port.test", "firewall_templates.#", "1"),
+				),
+			},
+			{
+				Config: testAccRustackPortConfig(api, "10.0.0.60"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_port.test", "ip_address", "10.0.0.60"),
+				),
+			},
+			{
+				ResourceName:            "rustack_port.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"vdc_id"},
+			},
+		},
+	})
+}
+
+func testAccRustackPortConfig(api *fakeRustackAPI, ipAddress string) string {
+	return testAccBaseConfig(api) + fmt.Sprintf(`
+resource "rustack_port" "test" {
+  vdc_id             = rustack_vdc.test.id
+  network_id         = rustack_vdc.test.default_network_id
+  ip_address         = %q
+  firewall_templates = [%q]
+  tags               = ["acc"]
+}
+`, ipAddress, api.FirewallID)
+}
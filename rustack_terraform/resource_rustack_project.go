@@ -2,7 +2,7 @@ package rustack_terraform
 
 import (
 	"context"
-	"log"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -21,12 +21,19 @@ func resourceRustackProject() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
-		Schema: args,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+		Schema:        args,
+		CustomizeDiff: customizeDiffTags,
 	}
 }
 
 func resourceRustackProjectCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	client_id := manager.ClientID
 	var client *rustack.Client
 	var err error
@@ -34,12 +41,12 @@ func resourceRustackProjectCreate(ctx context.Context, d *schema.ResourceData, m
 	if client_id != "" {
 		client, err = manager.GetClient(client_id)
 		if err != nil {
-			return diag.Errorf("Error getting client: %s", err)
+			return apiErrorf("Error getting client: %s", err)
 		}
 	} else {
 		allClients, err := manager.GetClients()
 		if err != nil {
-			return diag.Errorf("Error there are no clients available for management: %s", err)
+			return apiErrorf("Error there are no clients available for management: %s", err)
 		}
 		if len(allClients) == 0 {
 			return diag.Errorf("There are no available clients")
@@ -54,82 +61,94 @@ func resourceRustackProjectCreate(ctx context.Context, d *schema.ResourceData, m
 	project := rustack.NewProject(
 		d.Get("name").(string),
 	)
-	project.Tags = unmarshalTagNames(d.Get("tags"))
-	log.Printf("[DEBUG] Project create request: %#v", project)
+	project.Tags = expandTags(d, meta)
+	logDebug(ctx, subsystemProject, "Creating project", map[string]interface{}{"name": project.Name})
 	err = client.CreateProject(&project)
 	if err != nil {
-		return diag.Errorf("id: Error creating project: %s", err)
+		return apiErrorf("id: Error creating project: %s", err)
+	}
+	if err := waitLock(ctx, manager, &project); err != nil {
+		return apiErrorDiag(err)
 	}
-	project.WaitLock()
 
 	d.SetId(project.ID)
-	log.Printf("[INFO] Project created, ID: %s", d.Id())
+	logInfo(ctx, subsystemProject, "Project created", map[string]interface{}{"id": d.Id()})
 
 	return resourceRustackProjectRead(ctx, d, meta)
 }
 
 func resourceRustackProjectRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	project, err := manager.GetProject(d.Id())
 	if err != nil {
-		if err.(*rustack.RustackApiError).Code() == 404 {
+		if isNotFound(err) {
 			d.SetId("")
 			return nil
 		} else {
-			return diag.Errorf("id: Error getting project: %s", err)
+			return apiErrorf("id: Error getting project: %s", err)
 		}
 	}
 
 	d.SetId(project.ID)
 	d.Set("name", project.Name)
-	d.Set("tags", marshalTagNames(project.Tags))
+	if err := setTags(d, meta, project.Tags); err != nil {
+		return apiErrorDiag(err)
+	}
 
 	return nil
 }
 
 func resourceRustackProjectUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 
 	project, err := manager.GetProject(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Error getting project: %s", err)
+		return apiErrorf("id: Error getting project: %s", err)
+	}
+
+	if err := waitLock(ctx, manager, project); err != nil {
+		return apiErrorDiag(err)
 	}
 
 	if d.HasChange("name") {
 		project.Name = d.Get("name").(string)
 	}
-	if d.HasChange("tags") {
-		project.Tags = unmarshalTagNames(d.Get("tags"))
+	if d.HasChange("tags_all") {
+		project.Tags = expandTags(d, meta)
 	}
 	err = project.Update()
 	if err != nil {
-		return diag.Errorf("name: Error rename project: %s", err)
+		return apiErrorf("name: Error rename project: %s", err)
+	}
+	if err := waitLock(ctx, manager, project); err != nil {
+		return apiErrorDiag(err)
 	}
-	project.WaitLock()
 
-	log.Printf("[INFO] Updated Project, ID: %#v", project)
+	logInfo(ctx, subsystemProject, "Project updated", map[string]interface{}{"id": project.ID})
 
 	return resourceRustackProjectRead(ctx, d, meta)
 }
 
 func resourceRustackProjectDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 
 	projectId := d.Id()
 
 	project, err := manager.GetProject(projectId)
 	if err != nil {
-		return diag.Errorf("id: Error getting project: %s", err)
+		return apiErrorf("id: Error getting project: %s", err)
 	}
 
 	err = project.Delete()
 	if err != nil {
-		return diag.Errorf("Error deleting project: %s", err)
+		return apiErrorf("Error deleting project: %s", err)
+	}
+	if err := waitLock(ctx, manager, project); err != nil {
+		return apiErrorDiag(err)
 	}
-	project.WaitLock()
 
 	d.SetId("")
-	log.Printf("[INFO] Project deleted, ID: %s", projectId)
+	logInfo(ctx, subsystemProject, "Project deleted", map[string]interface{}{"id": projectId})
 
 	return nil
 }
@@ -0,0 +1,87 @@
+package rustack_terraform
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccRustackProject_basic(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_project", "project"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackProjectConfig(api, "terraform-acc"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckExists(api, "rustack_project.test", "project"),
+					resource.TestCheckResourceAttr("rustack_project.test", "name", "terraform-acc"),
+					resource.TestCheckResourceAttr("rustack_project.test", "tags.#", "1"),
+				),
+			},
+			{
+				// the update has to wait until the running task releases the lock
+				PreConfig: func() { api.Lock(api.Find("project", "terraform-acc"), 4) },
+				Config:    testAccRustackProjectConfig(api, "terraform-acc-renamed"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_project.test", "name", "terraform-acc-renamed"),
+				),
+			},
+			{
+				ResourceName:      "rustack_project.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccRustackProject_lockTimeout(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_project", "project"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackProjectTimeoutConfig(api, "terraform-acc"),
+			},
+			{
+				// the lock outlives the update timeout
+				PreConfig:   func() { api.Lock(api.Find("project", "terraform-acc"), 1<<30) },
+				Config:      testAccRustackProjectTimeoutConfig(api, "terraform-acc-renamed"),
+				ExpectError: regexp.MustCompile(`project "terraform-acc" \([0-9a-f-]+\) to be unlocked: it stayed\s+locked`),
+			},
+			{
+				PreConfig: func() { api.Lock(api.Find("project", "terraform-acc"), 0) },
+				Config:    testAccRustackProjectTimeoutConfig(api, "terraform-acc"),
+			},
+		},
+	})
+}
+
+func testAccRustackProjectConfig(api *fakeRustackAPI, name string) string {
+	return api.providerConfig() + `
+resource "rustack_project" "test" {
+  name = "` + name + `"
+  tags = ["acc"]
+}
+`
+}
+
+func testAccRustackProjectTimeoutConfig(api *fakeRustackAPI, name string) string {
+	return api.providerConfig() + `
+resource "rustack_project" "test" {
+  name = "` + name + `"
+
+  timeouts {
+    update = "2s"
+  }
+}
+`
+}
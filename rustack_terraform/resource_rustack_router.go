@@ -4,39 +4,48 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"sort"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/rustack-cloud-platform/rcp-go/rustack"
 )
 
 func resourceRustackRouter() *schema.Resource {
 	args := Defaults()
-	args.injectContextVdcById()
+	args.injectContextDefaultVdcById()
 	args.injectCreateRouter()
 
 	return &schema.Resource{
-		CreateContext: resourceRustackRouterCreate,
+		CreateContext: serializeInVdc(resourceRustackRouterCreate),
 		ReadContext:   resourceRustackRouterRead,
-		UpdateContext: resourceRustackRouterUpdate,
-		DeleteContext: resourceRustackRouterDelete,
+		UpdateContext: serializeInVdc(resourceRustackRouterUpdate),
+		DeleteContext: serializeInVdc(resourceRustackRouterDelete),
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
-		Schema: args,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+		Schema:        args,
+		CustomizeDiff: customdiff.All(customizeDiffDefaultVdc, customizeDiffTags),
 	}
 }
 
 func resourceRustackRouterCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 
+	tags := expandTags(d, meta)
 	var diagErr diag.Diagnostics
 	if d.Get("system").(bool) {
-		diagErr = setServiceRouter(d, manager)
+		diagErr = setServiceRouter(ctx, d, manager, tags)
 	} else {
-		diagErr = createRouter(d, manager)
+		diagErr = createRouter(ctx, d, manager, tags)
 	}
 	if diagErr != nil {
 		return diagErr
@@ -46,14 +55,14 @@ func resourceRustackRouterCreate(ctx context.Context, d *schema.ResourceData, me
 }
 
 func resourceRustackRouterRead(ctx context.Context, d *schema.ResourceData, meta interface{}) (diagErr diag.Diagnostics) {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	router, err := manager.GetRouter(d.Id())
 	if err != nil {
-		if err.(*rustack.RustackApiError).Code() == 404 {
+		if isNotFound(err) {
 			d.SetId("")
 			return nil
 		} else {
-			return diag.Errorf("id: Error getting Router: %s", err)
+			return apiErrorf("id: Error getting Router: %s", err)
 		}
 	}
 
@@ -61,88 +70,98 @@ func resourceRustackRouterRead(ctx context.Context, d *schema.ResourceData, meta
 	d.Set("name", router.Name)
 
 	d.Set("floating", router.Floating != nil)
-	d.Set("floating_ip", "")
+	d.Set("floating_id", "")
 	if router.Floating != nil {
-		d.Set("floating_ip", router.Floating.IpAddress)
+		d.Set("floating_id", router.Floating.ID)
 	}
 
-	ports := make([]*string, len(router.Ports))
-	for i, port := range router.Ports {
-		ports[i] = &port.ID
+	// Ports connected with rustack_port_attachment are not the router's to
+	// manage, so only the ports it connected itself are read back. All of
+	// them are read on import.
+	managed := d.Get("ports").(*schema.Set)
+	ports := make([]*string, 0, len(router.Ports))
+	for _, port := range router.Ports {
+		if managed.Len() == 0 || managed.Contains(port.ID) {
+			ports = append(ports, &port.ID)
+		}
 	}
 
 	d.Set("ports", ports)
 	d.Set("vdc_id", router.Vdc.Id)
-	d.Set("tags", marshalTagNames(router.Tags))
+	if err := setTags(d, meta, router.Tags); err != nil {
+		return apiErrorDiag(err)
+	}
 
 	return
 }
 
 func resourceRustackRouterUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	router, err := manager.GetRouter(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Error getting Router: %s", err)
+		return apiErrorf("id: Error getting Router: %s", err)
 	}
 	shouldUpdate := false
 	if d.HasChange("name") {
 		router.Name = d.Get("name").(string)
 		shouldUpdate = true
 	}
-	if d.HasChange("tags") {
-		router.Tags = unmarshalTagNames(d.Get("tags"))
+	if d.HasChange("tags_all") {
+		router.Tags = expandTags(d, meta)
 		shouldUpdate = true
 	}
 	if shouldUpdate {
 		if err := router.Update(); err != nil {
-			return diag.Errorf("error on router's update %s", err)
+			return apiErrorf("error on router's update %s", err)
 		}
 	}
 
-	if err := syncFloating(d, router); err != nil {
-		return diag.FromErr(err)
+	if err := syncFloating(ctx, d, manager, router); err != nil {
+		return apiErrorDiag(err)
 	}
 
 	// Disconnect ports and connect new
-	err = syncRouterPorts(d, manager, router)
+	err = syncRouterPorts(ctx, d, manager, router)
 	if err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
+	}
+	if err := waitLock(ctx, manager, router); err != nil {
+		return apiErrorDiag(err)
 	}
-	router.WaitLock()
 
 	return resourceRustackRouterRead(ctx, d, meta)
 }
 
 func resourceRustackRouterDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	portsIds := d.Get("ports").(*schema.Set).List()
 	routerId := d.Id()
 	router, err := manager.GetRouter(routerId)
 	if err != nil {
-		return diag.Errorf("id: Error getting Router: %s", err)
+		return apiErrorf("id: Error getting Router: %s", err)
 	}
 
 	// Disconnect custom ports from system router
 	if d.Get("system").(bool) {
 		if err != nil {
-			return diag.Errorf("Error getting service Network: %s", err)
+			return apiErrorf("Error getting service Network: %s", err)
 		}
 
 		for _, port := range router.Ports {
 			network, err := manager.GetNetwork(port.Network.ID)
 			if err != nil {
-				return diag.FromErr(err)
+				return apiErrorDiag(err)
 			}
 			if !network.IsDefault {
 				err = router.DisconnectPort(port)
 				if err != nil {
-					return diag.FromErr(err)
+					return apiErrorDiag(err)
 				}
 			}
 			if router.Floating == nil {
 				router.Floating = &rustack.Port{ID: "RANDOM_FIP"}
-				if err = repeatOnError(router.Update, router); err != nil {
-					return diag.Errorf("ERROR: Can't return router to default state: %s", err)
+				if err = runUnlocked(ctx, manager, router.Update, router); err != nil {
+					return apiErrorf("ERROR: Can't return router to default state: %s", err)
 				}
 			}
 		}
@@ -154,29 +173,38 @@ func resourceRustackRouterDelete(ctx context.Context, d *schema.ResourceData, me
 	for _, portId := range portsIds {
 		port, err := manager.GetPort(portId.(string))
 		if err != nil {
-			return diag.FromErr(err)
+			if isNotFound(err) {
+				continue
+			}
+			return apiErrorDiag(err)
+		}
+		// ports connected with rustack_port_attachment may have moved already
+		if port.Connected == nil || port.Connected.ID != router.ID {
+			continue
 		}
 		err = router.DisconnectPort(port)
 		if err != nil {
-			return diag.FromErr(err)
+			return apiErrorDiag(err)
 		}
 	}
 
-	if err = repeatOnError(router.Delete, router); err != nil {
-		return diag.Errorf("Error deleting Router: %s", err)
+	if err = runUnlocked(ctx, manager, router.Delete, router); err != nil {
+		return apiErrorf("Error deleting Router: %s", err)
+	}
+	if err := waitLock(ctx, manager, router); err != nil {
+		return apiErrorDiag(err)
 	}
-	router.WaitLock()
 
 	d.SetId("")
-	log.Printf("[INFO] Router deleted, ID: %s", routerId)
+	logInfo(ctx, subsystemRouter, "Router deleted", map[string]interface{}{"id": routerId})
 
 	return nil
 }
 
-func setServiceRouter(d *schema.ResourceData, manager *rustack.Manager) diag.Diagnostics {
-	router, err := getSystemRouter(d, manager)
+func setServiceRouter(ctx context.Context, d *schema.ResourceData, manager *rustack.Manager, tags []rustack.Tag) diag.Diagnostics {
+	router, err := getSystemRouter(ctx, d, manager, tags)
 	if err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
 	}
 
 	d.SetId(router.ID)
@@ -190,26 +218,26 @@ func setServiceRouter(d *schema.ResourceData, manager *rustack.Manager) diag.Dia
 	for i, portId := range portsIds {
 		port, err := manager.GetPort(portId.(string))
 		if err != nil {
-			return diag.FromErr(err)
+			return apiErrorDiag(err)
 		}
 		ports[i] = port
 	}
 
 	d.Set("ports", ports)
 
-	if err := syncFloating(d, router); err != nil {
-		return diag.FromErr(err)
+	if err := syncFloating(ctx, d, manager, router); err != nil {
+		return apiErrorDiag(err)
 	}
 
-	log.Printf("[INFO] Router, ID: %s", d.Id())
+	logInfo(ctx, subsystemRouter, "Router", map[string]interface{}{"id": d.Id()})
 
 	return nil
 }
 
-func createRouter(d *schema.ResourceData, manager *rustack.Manager) (diagErr diag.Diagnostics) {
+func createRouter(ctx context.Context, d *schema.ResourceData, manager *rustack.Manager, tags []rustack.Tag) (diagErr diag.Diagnostics) {
 	vdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("ports: Error getting Ports from vdc: %s", err)
+		return apiErrorf("ports: Error getting Ports from vdc: %s", err)
 	}
 	if _, ok := d.GetOk("ports"); !ok {
 		return diag.Errorf("ports: Error You should setup a port for non default routers")
@@ -222,40 +250,44 @@ func createRouter(d *schema.ResourceData, manager *rustack.Manager) (diagErr dia
 	}
 
 	router := rustack.NewRouter(d.Get("name").(string), floatingIp)
-	router.Tags = unmarshalTagNames(d.Get("tags"))
+	router.Tags = tags
 	portsIds := d.Get("ports").(*schema.Set).List()
 	ports := make([]*rustack.Port, len(portsIds))
 
 	for i, portId := range portsIds {
 		port, err := manager.GetPort(portId.(string))
 		if err != nil {
-			return diag.FromErr(err)
+			return apiErrorDiag(err)
 		}
 		ports[i] = port
 	}
 
 	router.Vdc.Id = vdc.ID
 
-	log.Printf("[DEBUG] Router create request: %#v", router)
-	vdc.WaitLock()
+	logDebug(ctx, subsystemRouter, "Creating router", map[string]interface{}{"name": router.Name, "vdc_id": vdc.ID})
+	if err := waitLock(ctx, manager, vdc); err != nil {
+		return apiErrorDiag(err)
+	}
 
 	err = vdc.CreateRouter(&router, ports...)
 	if err != nil {
-		return diag.Errorf("Error creating Router: %s", err)
+		return apiErrorf("Error creating Router: %s", err)
+	}
+	if err := waitLock(ctx, manager, &router); err != nil {
+		return apiErrorDiag(err)
 	}
-	router.WaitLock()
 
 	d.SetId(router.ID)
-	d.Set("floating", router.Floating)
+	d.Set("floating", router.Floating != nil)
 	if router.Floating != nil {
 		d.Set("floating_id", router.Floating.ID)
 	}
-	log.Printf("[INFO] Router created, ID: %s", router.ID)
+	logInfo(ctx, subsystemRouter, "Router created", map[string]interface{}{"id": router.ID})
 
 	return
 }
 
-func getSystemRouter(d *schema.ResourceData, manager *rustack.Manager) (router *rustack.Router, err error) {
+func getSystemRouter(ctx context.Context, d *schema.ResourceData, manager *rustack.Manager, tags []rustack.Tag) (router *rustack.Router, err error) {
 	vdc, err := GetVdcById(d, manager)
 	if err != nil {
 		return nil, fmt.Errorf("ERROR: Can't get Ports from vdc: %s", err)
@@ -273,14 +305,13 @@ func getSystemRouter(d *schema.ResourceData, manager *rustack.Manager) (router *
 		return nil, fmt.Errorf("ERROR: Default router not found in vdc %s", vdc.ID)
 	}
 	d.SetId(router.ID)
-	tags := unmarshalTagNames(d.Get("tags"))
 	shouldUpdate := false
 	if len(tags) != len(router.Tags) {
 		router.Tags = tags
 		shouldUpdate = true
 	} else {
 		sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
-		sort.Slice(router.Tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+		sort.Slice(router.Tags, func(i, j int) bool { return router.Tags[i].Name < router.Tags[j].Name })
 		for i := 0; i < len(tags); i++ {
 			if tags[i].Name != router.Tags[i].Name {
 				router.Tags = tags
@@ -295,7 +326,7 @@ func getSystemRouter(d *schema.ResourceData, manager *rustack.Manager) (router *
 		}
 	}
 
-	err = syncRouterPorts(d, manager, router)
+	err = syncRouterPorts(ctx, d, manager, router)
 	if err != nil {
 		return nil, err
 	}
@@ -318,9 +349,10 @@ func getSystemRouter(d *schema.ResourceData, manager *rustack.Manager) (router *
 	return
 }
 
-func syncRouterPorts(d *schema.ResourceData, manager *rustack.Manager, router *rustack.Router) (err error) {
+func syncRouterPorts(ctx context.Context, d *schema.ResourceData, manager *rustack.Manager, router *rustack.Router) (err error) {
 	portsIds := d.Get("ports").(*schema.Set).List()
 	router_id := d.Id()
+	oldPorts, _ := d.GetChange("ports")
 
 	for _, port := range router.Ports {
 		found := false
@@ -331,12 +363,15 @@ func syncRouterPorts(d *schema.ResourceData, manager *rustack.Manager, router *r
 			}
 		}
 
-		if !found {
+		// Only the ports removed from the router are disconnected, the
+		// others may be connected with rustack_port_attachment
+		if !found && (d.IsNewResource() || oldPorts.(*schema.Set).Contains(port.ID)) {
 			if port.Connected != nil && port.Connected.ID == router_id {
-				log.Printf("Port %s found on vm and not mentioned in the state."+
-					" Port will be detached", port.ID)
+				logInfo(ctx, subsystemRouter, "Port is connected to the router but not mentioned in the state, detaching it", map[string]interface{}{"port_id": port.ID})
 				router.DisconnectPort(port)
-				port.WaitLock()
+				if err := waitLock(ctx, manager, port); err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -360,13 +395,15 @@ func syncRouterPorts(d *schema.ResourceData, manager *rustack.Manager, router *r
 			}
 			if port.Connected != nil && port.Connected.ID != router_id {
 				router.DisconnectPort(port)
-				port.WaitLock()
+				if err := waitLock(ctx, manager, port); err != nil {
+					return err
+				}
 			}
 			port, err = manager.GetPort(portId.(string))
 			if err != nil {
 				return fmt.Errorf("ERROR: Cannot get port `%s`: %s", portId, err)
 			}
-			log.Printf("Port `%s` will be Attached", port.ID)
+			logInfo(ctx, subsystemRouter, "Attaching port", map[string]interface{}{"port_id": port.ID})
 			if err := router.ConnectPort(port, true); err != nil {
 				return fmt.Errorf("ERROR: Cannot attach port `%s`: %s", port.ID, err)
 			}
@@ -376,12 +413,12 @@ func syncRouterPorts(d *schema.ResourceData, manager *rustack.Manager, router *r
 	return
 }
 
-func syncFloating(d *schema.ResourceData, router *rustack.Router) (err error) {
+func syncFloating(ctx context.Context, d *schema.ResourceData, manager *rustack.Manager, router *rustack.Router) (err error) {
 	floating := d.Get("floating")
 	if floating.(bool) && (router.Floating == nil) {
 		// add floating if it was removed
 		router.Floating = &rustack.Port{ID: "RANDOM_FIP"}
-		if err = repeatOnError(router.Update, router); err != nil {
+		if err = runUnlocked(ctx, manager, router.Update, router); err != nil {
 			return fmt.Errorf("ERROR: Can't update Router: %s", err)
 		}
 		d.Set("floating", true)
@@ -390,7 +427,7 @@ func syncFloating(d *schema.ResourceData, router *rustack.Router) (err error) {
 		// remove floating if needed
 		router.Floating = nil
 
-		if err = repeatOnError(router.Update, router); err != nil {
+		if err = runUnlocked(ctx, manager, router.Update, router); err != nil {
 			return fmt.Errorf("ERROR: Can't update Router: %s", err)
 		}
 	} else if floating.(bool) && (router.Floating != nil) {
@@ -400,7 +437,7 @@ func syncFloating(d *schema.ResourceData, router *rustack.Router) (err error) {
 	return
 }
 
-func preparePortsToConnect(manager *rustack.Manager, d *schema.ResourceData) (ports []*rustack.Port, err error) {
+func preparePortsToConnect(ctx context.Context, manager *rustack.Manager, d *schema.ResourceData) (ports []*rustack.Port, err error) {
 	netArray := d.Get("networks").(*schema.Set).List()
 	vdc, err := GetVdcById(d, manager)
 	if err != nil {
@@ -438,7 +475,9 @@ func preparePortsToConnect(manager *rustack.Manager, d *schema.ResourceData) (po
 		}
 		for _, port := range vdcPorts {
 			if port.Network != nil && port.Network.ID == network.ID {
-				port.Network.WaitLock()
+				if err := waitLock(ctx, manager, port.Network); err != nil {
+					return nil, err
+				}
 			}
 			if port.Connected != nil && port.Connected.ID == router.ID && port.Network.ID == network.ID {
 				ports = append(ports, port)
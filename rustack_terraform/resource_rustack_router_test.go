@@ -0,0 +1,72 @@
+package rustack_terraform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccRustackRouter_basic(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_router", "router"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackRouterConfig(api, "terraform-acc"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckExists(api, "rustack_router.test", "router"),
+					resource.TestCheckResourceAttr("rustack_router.test", "name", "terraform-acc"),
+					resource.TestCheckResourceAttr("rustack_router.test", "ports.#", "1"),
+					resource.TestCheckResourceAttrPair("rustack_router.test", "ports.0", "rustack_port.test", "id"),
+				),
+			},
+			{
+				Config: testAccRustackRouterConfig(api, "terraform-acc-renamed"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_router.test", "name", "terraform-acc-renamed"),
+				),
+			},
+			{
+				ResourceName:            "rustack_router.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"system", "is_default"},
+			},
+		},
+	})
+}
+
+func testAccRustackRouterConfig(api *fakeRustackAPI, name string) string {
+	return testAccBaseConfig(api) + fmt.Sprintf(`
+resource "rustack_network" "test" {
+  vdc_id = rustack_vdc.test.id
+  name   = "terraform-acc"
+
+  subnets {
+    cidr     = "10.20.0.0/24"
+    dhcp     = true
+    gateway  = "10.20.0.1"
+    start_ip = "10.20.0.2"
+    end_ip   = "10.20.0.254"
+    dns      = ["8.8.8.8"]
+  }
+}
+
+resource "rustack_port" "test" {
+  vdc_id     = rustack_vdc.test.id
+  network_id = rustack_network.test.id
+  ip_address = "10.20.0.1"
+}
+
+resource "rustack_router" "test" {
+  vdc_id = rustack_vdc.test.id
+  name   = %q
+  ports  = [rustack_port.test.id]
+  tags   = ["acc"]
+}
+`, name)
+}
@@ -2,17 +2,17 @@ package rustack_terraform
 
 import (
 	"context"
-	"log"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/rustack-cloud-platform/rcp-go/rustack"
 )
 
 func resourceRustackS3Storage() *schema.Resource {
 	args := Defaults()
-	args.injectContextProjectById()
+	args.injectContextDefaultProjectById()
 	args.injectCreateS3Storage()
 
 	return &schema.Resource{
@@ -25,99 +25,110 @@ func resourceRustackS3Storage() *schema.Resource {
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
 			Delete: schema.DefaultTimeout(10 * time.Minute),
 		},
-		Schema: args,
+		Schema:        args,
+		CustomizeDiff: customdiff.All(customizeDiffDefaultProject, customizeDiffTags),
 	}
 }
 
 func resourceRustackS3StorageCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	project, err := GetProjectById(d, manager)
 	if err != nil {
-		return diag.Errorf("project_id: Error getting Project: %s", err)
+		return apiErrorf("project_id: Error getting Project: %s", err)
 	}
 	name := d.Get("name").(string)
 	backend := d.Get("backend").(string)
 	newS3Storage := rustack.NewS3Storage(name, backend)
-	newS3Storage.Tags = unmarshalTagNames(d.Get("tags"))
+	newS3Storage.Tags = expandTags(d, meta)
 
 	err = project.CreateS3Storage(&newS3Storage)
 	if err != nil {
-		return diag.Errorf("Error creating S3Storage: %s", err)
+		return apiErrorf("Error creating S3Storage: %s", err)
 	}
 
-	newS3Storage.WaitLock()
+	if err := waitLock(ctx, manager, &newS3Storage); err != nil {
+		return apiErrorDiag(err)
+	}
 	d.SetId(newS3Storage.ID)
-	log.Printf("[INFO] S3Storage created, ID: %s", d.Id())
+	logInfo(ctx, subsystemS3, "S3Storage created", map[string]interface{}{"id": d.Id()})
 
 	return resourceRustackS3StorageRead(ctx, d, meta)
 }
 
 func resourceRustackS3StorageUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 
 	s3, err := manager.GetS3Storage(d.Id())
 	if err != nil {
-		return diag.Errorf("Error getting S3Storage: %s", err)
+		return apiErrorf("Error getting S3Storage: %s", err)
 	}
 	if d.HasChange("name") {
 		s3.Name = d.Get("name").(string)
 	}
-	if d.HasChange("tags") {
-		s3.Tags = unmarshalTagNames(d.Get("tags"))
+	if d.HasChange("tags_all") {
+		s3.Tags = expandTags(d, meta)
 	}
 
 	err = s3.Update()
 	if err != nil {
-		return diag.Errorf("Error updating S3Storage: %s", err)
+		return apiErrorf("Error updating S3Storage: %s", err)
+	}
+	if err := waitLock(ctx, manager, s3); err != nil {
+		return apiErrorDiag(err)
 	}
-	s3.WaitLock()
-	log.Printf("[INFO] S3Storage updated, ID: %s", d.Id())
+	logInfo(ctx, subsystemS3, "S3Storage updated", map[string]interface{}{"id": d.Id()})
 
 	return resourceRustackS3StorageRead(ctx, d, meta)
 }
 
 func resourceRustackS3StorageRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	S3Storage, err := manager.GetS3Storage(d.Id())
 	if err != nil {
-		if err.(*rustack.RustackApiError).Code() == 404 {
+		if isNotFound(err) {
 			d.SetId("")
 			return nil
 		} else {
-			return diag.Errorf("id: Error getting S3Storage: %s", err)
+			return apiErrorf("id: Error getting S3Storage: %s", err)
 		}
 	}
 
 	d.SetId(S3Storage.ID)
 	d.Set("name", S3Storage.Name)
 	d.Set("backend", S3Storage.Backend)
-	d.Set("project", S3Storage.Project.ID)
+	d.Set("project_id", S3Storage.Project.ID)
 	d.Set("client_endpoint", S3Storage.ClientEndpoint)
 	d.Set("secret_key", S3Storage.SecretKey)
 	d.Set("access_key", S3Storage.AccessKey)
-	d.Set("tags", marshalTagNames(S3Storage.Tags))
+	if err := setTags(d, meta, S3Storage.Tags); err != nil {
+		return apiErrorDiag(err)
+	}
 
 	return nil
 }
 
 func resourceRustackS3StorageDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	s3_id := d.Id()
 	s3, err := manager.GetS3Storage(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Error getting S3Storage: %s", err)
+		return apiErrorf("id: Error getting S3Storage: %s", err)
 	}
 
 	err = s3.Delete()
 	if err != nil {
-		return diag.Errorf("Error deleting S3Storage: %s", err)
+		return apiErrorf("Error deleting S3Storage: %s", err)
+	}
+	if err := waitLock(ctx, manager, s3); err != nil {
+		return apiErrorDiag(err)
 	}
-	s3.WaitLock()
 
 	d.SetId("")
-	log.Printf("[INFO] S3Storage deleted, ID: %s", s3_id)
+	logInfo(ctx, subsystemS3, "S3Storage deleted", map[string]interface{}{"id": s3_id})
 
 	return nil
 }
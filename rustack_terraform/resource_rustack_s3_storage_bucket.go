@@ -2,7 +2,6 @@ package rustack_terraform
 
 import (
 	"context"
-	"log"
 	"regexp"
 	"time"
 
@@ -26,6 +25,8 @@ func resourceRustackS3StorageBucket() *schema.Resource {
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
 			Delete: schema.DefaultTimeout(10 * time.Minute),
 		},
 		Schema: args,
@@ -35,12 +36,12 @@ func resourceRustackS3StorageBucket() *schema.Resource {
 var re_for_name = regexp.MustCompile(`^[A-z0-9\-]+$`)
 
 func resourceRustackS3StorageBucketCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	s3_id := d.Get("s3_storage_id").(string)
 
 	s3, err := manager.GetS3Storage(s3_id)
 	if err != nil {
-		return diag.Errorf("id: Error getting S3Storage: %s", err)
+		return apiErrorf("id: Error getting S3Storage: %s", err)
 	}
 	var S3StorageBucket rustack.S3StorageBucket
 	if len(re_for_name.FindStringSubmatch(d.Get("name").(string))) > 0 {
@@ -51,27 +52,27 @@ func resourceRustackS3StorageBucketCreate(ctx context.Context, d *schema.Resourc
 
 	err = s3.CreateBucket(&S3StorageBucket)
 	if err != nil {
-		return diag.Errorf("Error creating S3StorageBucket: %s", err)
+		return apiErrorf("Error creating S3StorageBucket: %s", err)
 	}
 
 	d.SetId(S3StorageBucket.ID)
-	log.Printf("[INFO] S3StorageBucket created, ID: %s", d.Id())
+	logInfo(ctx, subsystemS3, "S3StorageBucket created", map[string]interface{}{"id": d.Id()})
 
 	return resourceRustackS3StorageBucketRead(ctx, d, meta)
 }
 
 func resourceRustackS3StorageBucketUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	s3_id := d.Get("s3_storage_id").(string)
 
 	s3, err := manager.GetS3Storage(s3_id)
 	if err != nil {
-		return diag.Errorf("id: Error getting S3Storage: %s", err)
+		return apiErrorf("id: Error getting S3Storage: %s", err)
 	}
 
 	bucket, err := s3.GetBucket(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Error getting S3StorageBucket: %s", err)
+		return apiErrorf("id: Error getting S3StorageBucket: %s", err)
 	}
 	if d.HasChange("name") {
 		if len(re_for_name.FindStringSubmatch(d.Get("name").(string))) > 0 {
@@ -83,29 +84,34 @@ func resourceRustackS3StorageBucketUpdate(ctx context.Context, d *schema.Resourc
 
 	err = bucket.Update()
 	if err != nil {
-		return diag.Errorf("Error updating S3StorageBucket: %s", err)
+		return apiErrorf("Error updating S3StorageBucket: %s", err)
 	}
-	log.Printf("[INFO] S3StorageBucket updated, ID: %s", d.Id())
+	logInfo(ctx, subsystemS3, "S3StorageBucket updated", map[string]interface{}{"id": d.Id()})
 
 	return resourceRustackS3StorageBucketRead(ctx, d, meta)
 }
 
 func resourceRustackS3StorageBucketRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	s3_id := d.Get("s3_storage_id").(string)
 
 	s3, err := manager.GetS3Storage(s3_id)
 	if err != nil {
-		return diag.Errorf("id: Error getting S3Storage: %s", err)
+		// the bucket is removed together with its s3 storage
+		if isNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return apiErrorf("id: Error getting S3Storage: %s", err)
 	}
 
 	bucket, err := s3.GetBucket(d.Id())
 	if err != nil {
-		if err.(*rustack.RustackApiError).Code() == 404 {
+		if isNotFound(err) {
 			d.SetId("")
 			return nil
 		} else {
-			return diag.Errorf("id: Error getting S3StorageBucket: %s", err)
+			return apiErrorf("id: Error getting S3StorageBucket: %s", err)
 		}
 	}
 
@@ -117,26 +123,26 @@ func resourceRustackS3StorageBucketRead(ctx context.Context, d *schema.ResourceD
 }
 
 func resourceRustackS3StorageBucketDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	s3_id := d.Get("s3_storage_id").(string)
 
 	s3, err := manager.GetS3Storage(s3_id)
 	if err != nil {
-		return diag.Errorf("id: Error getting S3Storage: %s", err)
+		return apiErrorf("id: Error getting S3Storage: %s", err)
 	}
 
 	bucket, err := s3.GetBucket(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Error getting S3StorageBucket: %s", err)
+		return apiErrorf("id: Error getting S3StorageBucket: %s", err)
 	}
 
 	err = bucket.Delete()
 	if err != nil {
-		return diag.Errorf("Error deleting S3StorageBucket: %s", err)
+		return apiErrorf("Error deleting S3StorageBucket: %s", err)
 	}
 
 	d.SetId("")
-	log.Printf("[INFO] S3StorageBucket deleted, ID: %s", s3_id)
+	logInfo(ctx, subsystemS3, "S3StorageBucket deleted", map[string]interface{}{"id": s3_id})
 
 	return nil
 }
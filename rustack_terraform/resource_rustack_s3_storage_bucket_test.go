@@ -0,0 +1,53 @@
+package rustack_terraform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccRustackS3StorageBucket_basic(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_s3_storage_bucket", "bucket"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackS3StorageBucketConfig(api, "terraform-acc"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckExists(api, "rustack_s3_storage_bucket.test", "bucket"),
+					resource.TestCheckResourceAttr("rustack_s3_storage_bucket.test", "name", "terraform-acc"),
+					resource.TestCheckResourceAttrSet("rustack_s3_storage_bucket.test", "external_name"),
+				),
+			},
+			{
+				Config: testAccRustackS3StorageBucketConfig(api, "terraform-acc-renamed"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_s3_storage_bucket.test", "name", "terraform-acc-renamed"),
+				),
+			},
+		},
+	})
+}
+
+func testAccRustackS3StorageBucketConfig(api *fakeRustackAPI, name string) string {
+	return api.providerConfig() + fmt.Sprintf(`
+resource "rustack_project" "test" {
+  name = "terraform-acc"
+}
+
+resource "rustack_s3_storage" "test" {
+  project_id = rustack_project.test.id
+  name       = "terraform-acc"
+  backend    = "minio"
+}
+
+resource "rustack_s3_storage_bucket" "test" {
+  s3_storage_id = rustack_s3_storage.test.id
+  name          = %q
+}
+`, name)
+}
@@ -0,0 +1,50 @@
+package rustack_terraform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccRustackS3Storage_basic(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_s3_storage", "s3_storage"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackS3StorageConfig(api, "terraform-acc"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckExists(api, "rustack_s3_storage.test", "s3_storage"),
+					resource.TestCheckResourceAttr("rustack_s3_storage.test", "name", "terraform-acc"),
+					resource.TestCheckResourceAttr("rustack_s3_storage.test", "backend", "minio"),
+					resource.TestCheckResourceAttrSet("rustack_s3_storage.test", "access_key"),
+					resource.TestCheckResourceAttrSet("rustack_s3_storage.test", "secret_key"),
+				),
+			},
+			{
+				Config: testAccRustackS3StorageConfig(api, "terraform-acc-renamed"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_s3_storage.test", "name", "terraform-acc-renamed"),
+				),
+			},
+		},
+	})
+}
+
+func testAccRustackS3StorageConfig(api *fakeRustackAPI, name string) string {
+	return api.providerConfig() + fmt.Sprintf(`
+resource "rustack_project" "test" {
+  name = "terraform-acc"
+}
+
+resource "rustack_s3_storage" "test" {
+  project_id = rustack_project.test.id
+  name       = %q
+  backend    = "minio"
+}
+`, name)
+}
@@ -2,11 +2,11 @@ package rustack_terraform
 
 import (
 	"context"
-	"log"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/rustack-cloud-platform/rcp-go/rustack"
 )
@@ -17,55 +17,63 @@ func resourceRustackVdc() *schema.Resource {
 	args.injectContextHypervisorById()
 
 	return &schema.Resource{
-		CreateContext: resourceRustackVdcCreate,
+		CreateContext: serializeInProject(resourceRustackVdcCreate),
 		ReadContext:   resourceRustackVdcRead,
-		UpdateContext: resourceRustackVdcUpdate,
-		DeleteContext: resourceRustackVdcDelete,
+		UpdateContext: serializeInProject(resourceRustackVdcUpdate),
+		DeleteContext: serializeInProject(resourceRustackVdcDelete),
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
 			Delete: schema.DefaultTimeout(10 * time.Minute),
 		},
 		Schema: args,
-		CustomizeDiff: func(ctx context.Context, rd *schema.ResourceDiff, i interface{}) error {
-			if rd.Id() != "" && !rd.HasChange("project_id") {
-				rd.Clear("id")
-				rd.Clear("default_network_id")
-			}
-			return nil
-		},
+		CustomizeDiff: customdiff.All(
+			customizeDiffDefaultProject,
+			func(ctx context.Context, rd *schema.ResourceDiff, i interface{}) error {
+				if rd.Id() != "" && !rd.HasChange("project_id") {
+					rd.Clear("id")
+					rd.Clear("default_network_id")
+				}
+				return nil
+			},
+			customizeDiffTags,
+		),
 	}
 }
 
 func resourceRustackVdcCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetProject, err := manager.GetProject(d.Get("project_id").(string))
 	if err != nil {
-		return diag.Errorf("project_id: Error getting project: %s", err)
+		return apiErrorf("project_id: Error getting project: %s", err)
 	}
 
 	targetHypervisor, err := GetHypervisorById(d, manager, targetProject)
 	if err != nil {
-		return diag.Errorf("hypervisor_id: Error getting Hypervisor: %s", err)
+		return apiErrorf("hypervisor_id: Error getting Hypervisor: %s", err)
 	}
 
 	vdc := rustack.NewVdc(d.Get("name").(string), targetHypervisor)
-	vdc.Tags = unmarshalTagNames(d.Get("tags"))
+	vdc.Tags = expandTags(d, meta)
 	// if we creating multiple vdc at once, there are need some time to get new vnid
 	f := func() error { return targetProject.CreateVdc(&vdc) }
-	err = repeatOnError(f, targetProject)
+	err = runUnlocked(ctx, manager, f, targetProject)
 
 	if err != nil {
-		return diag.Errorf("Error creating vdc: %s", err)
+		return apiErrorf("Error creating vdc: %s", err)
 	}
 
-	vdc.WaitLock()
+	if err := waitLock(ctx, manager, &vdc); err != nil {
+		return apiErrorDiag(err)
+	}
 	if mtu, ok := d.GetOk("default_network_mtu"); ok {
 		networks, err := vdc.GetNetworks(rustack.Arguments{"defaults_only": "true"})
 		if err != nil {
-			return diag.Errorf("Error getting vdc networks: %s", err)
+			return apiErrorf("Error getting vdc networks: %s", err)
 		}
 		if len(networks) != 1 {
 			return diag.Errorf("Expected 1 network, got %d networks", len(networks))
@@ -75,30 +83,30 @@ func resourceRustackVdcCreate(ctx context.Context, d *schema.ResourceData, meta
 		network.Mtu = &mtuValue
 		err = network.Update()
 		if err != nil {
-			return diag.Errorf("Error updating vdc default network: %s", err)
+			return apiErrorf("Error updating vdc default network: %s", err)
 		}
 	}
 	vdc.GetNetworks()
 	d.SetId(vdc.ID)
-	log.Printf("[INFO] VDC created, ID: %s", d.Id())
+	logInfo(ctx, subsystemVdc, "VDC created", map[string]interface{}{"id": d.Id()})
 
 	return resourceRustackVdcRead(ctx, d, meta)
 }
 
 func resourceRustackVdcRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	vdc, err := manager.GetVdc(d.Id())
 	if err != nil {
-		if err.(*rustack.RustackApiError).Code() == 404 {
+		if isNotFound(err) {
 			d.SetId("")
 			return nil
 		} else {
-			return diag.Errorf("id: Error getting vdc: %s", err)
+			return apiErrorf("id: Error getting vdc: %s", err)
 		}
 	}
 	networks, err := vdc.GetNetworks(rustack.Arguments{"defaults_only": "true"})
 	if err != nil {
-		return diag.Errorf("error getting default network: %s", err)
+		return apiErrorf("error getting default network: %s", err)
 	}
 	if len(networks) != 1 {
 		return diag.Errorf("expected 1 default network, receive %d default networks", len(networks))
@@ -106,7 +114,7 @@ func resourceRustackVdcRead(ctx context.Context, d *schema.ResourceData, meta in
 	network := networks[0]
 	subnets, err := network.GetSubnets()
 	if err != nil {
-		return diag.Errorf("subnets: Error getting subnets: %s", err)
+		return apiErrorf("subnets: Error getting subnets: %s", err)
 	}
 
 	flattenedSubnets := make([]map[string]interface{}, len(subnets))
@@ -132,11 +140,13 @@ func resourceRustackVdcRead(ctx context.Context, d *schema.ResourceData, meta in
 		"default_network_name":    network.Name,
 		"default_network_subnets": flattenedSubnets,
 		"default_network_mtu":     network.Mtu,
-		"tags":                    marshalTagNames(vdc.Tags),
 	}
 
 	if err := setResourceDataFromMap(d, flattenedVdc); err != nil {
-		return diag.FromErr(err)
+		return apiErrorDiag(err)
+	}
+	if err := setTags(d, meta, vdc.Tags); err != nil {
+		return apiErrorDiag(err)
 	}
 
 	d.SetId(vdc.ID)
@@ -144,11 +154,11 @@ func resourceRustackVdcRead(ctx context.Context, d *schema.ResourceData, meta in
 }
 
 func resourceRustackVdcUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 
 	vdc, err := manager.GetVdc(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Error getting vdc: %s", err)
+		return apiErrorf("id: Error getting vdc: %s", err)
 	}
 	if d.HasChange("hypervisor_id") {
 		return diag.Errorf("hypervisor_id: you can`t change hypervisor type on created vdc")
@@ -156,17 +166,17 @@ func resourceRustackVdcUpdate(ctx context.Context, d *schema.ResourceData, meta
 	if d.HasChange("name") {
 		vdc.Name = d.Get("name").(string)
 	}
-	if d.HasChange("tags") {
-		vdc.Tags = unmarshalTagNames(d.Get("tags"))
+	if d.HasChange("tags_all") {
+		vdc.Tags = expandTags(d, meta)
 	}
 	err = vdc.Update()
 	if err != nil {
-		return diag.Errorf("name: Error rename vdc: %s", err)
+		return apiErrorf("name: Error rename vdc: %s", err)
 	}
 	if d.HasChange("default_network_mtu") {
 		networks, err := vdc.GetNetworks(rustack.Arguments{"defaults_only": "true"})
 		if err != nil {
-			return diag.Errorf("Error getting vdc networks: %s", err)
+			return apiErrorf("Error getting vdc networks: %s", err)
 		}
 		if len(networks) != 1 {
 			return diag.Errorf("Expected 1 network, got %d networks", len(networks))
@@ -181,27 +191,31 @@ func resourceRustackVdcUpdate(ctx context.Context, d *schema.ResourceData, meta
 		}
 		err = network.Update()
 		if err != nil {
-			return diag.Errorf("Error updating vdc default network: %s", err)
+			return apiErrorf("Error updating vdc default network: %s", err)
 		}
 	}
 
-	vdc.WaitLock()
+	if err := waitLock(ctx, manager, vdc); err != nil {
+		return apiErrorDiag(err)
+	}
 
 	return resourceRustackVdcRead(ctx, d, meta)
 }
 
 func resourceRustackVdcDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	vdc, err := manager.GetVdc(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Error getting vdc: %s", err)
+		return apiErrorf("id: Error getting vdc: %s", err)
 	}
 
 	err = vdc.Delete()
 	if err != nil {
-		return diag.Errorf("Error deleting vdc: %s", err)
+		return apiErrorf("Error deleting vdc: %s", err)
+	}
+	if err := waitLock(ctx, manager, vdc); err != nil {
+		return apiErrorDiag(err)
 	}
-	vdc.WaitLock()
 
 	return nil
 }
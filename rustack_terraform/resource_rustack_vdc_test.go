@@ -0,0 +1,58 @@
+package rustack_terraform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccRustackVdc_basic(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_vdc", "vdc"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackVdcConfig(api, "terraform-acc"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckExists(api, "rustack_vdc.test", "vdc"),
+					resource.TestCheckResourceAttr("rustack_vdc.test", "name", "terraform-acc"),
+					resource.TestCheckResourceAttr("rustack_vdc.test", "hypervisor_id", api.HypervisorID),
+					resource.TestCheckResourceAttrSet("rustack_vdc.test", "default_network_id"),
+					resource.TestCheckResourceAttr("rustack_vdc.test", "default_network_subnets.#", "1"),
+				),
+			},
+			{
+				Config: testAccRustackVdcConfig(api, "terraform-acc-renamed"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_vdc.test", "name", "terraform-acc-renamed"),
+				),
+			},
+			{
+				ResourceName:      "rustack_vdc.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+				// hypervisor_id is not read back from the API
+				ImportStateVerifyIgnore: []string{"hypervisor_id"},
+			},
+		},
+	})
+}
+
+func testAccRustackVdcConfig(api *fakeRustackAPI, name string) string {
+	return api.providerConfig() + fmt.Sprintf(`
+resource "rustack_project" "test" {
+  name = "terraform-acc"
+}
+
+resource "rustack_vdc" "test" {
+  name          = %q
+  project_id    = rustack_project.test.id
+  hypervisor_id = %q
+  tags          = ["acc"]
+}
+`, name, api.HypervisorID)
+}
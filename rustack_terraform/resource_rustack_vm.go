@@ -2,10 +2,11 @@ package rustack_terraform
 
 import (
 	"context"
-	"log"
+	"fmt"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/rustack-cloud-platform/rcp-go/rustack"
 )
@@ -13,22 +14,32 @@ import (
 func resourceRustackVm() *schema.Resource {
 	args := Defaults()
 	args.injectCreateVm()
-	args.injectContextVdcById()
+	args.injectContextDefaultVdcById()
 	args.injectContextTemplateById() // override template_id
 
 	return &schema.Resource{
-		CreateContext: resourceRustackVmCreate,
+		CreateContext: serializeInVdc(resourceRustackVmCreate),
 		ReadContext:   resourceRustackVmRead,
-		UpdateContext: resourceRustackVmUpdate,
-		DeleteContext: resourceRustackVmDelete,
+		UpdateContext: serializeInVdc(resourceRustackVmUpdate),
+		DeleteContext: serializeInVdc(resourceRustackVmDelete),
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
 			Delete: schema.DefaultTimeout(10 * time.Minute),
 		},
 		Schema: args,
+		CustomizeDiff: customdiff.All(
+			customizeDiffDefaultVdc,
+			customizeDiffVmTemplate,
+			customizeDiffVmSizing,
+			customizeDiffVmSystemDisk,
+			customizeDiffVmDataDisks,
+			customizeDiffTags,
+		),
 	}
 }
 
@@ -50,23 +61,36 @@ func getVmPortsIds(d *schema.ResourceData) (portsIds []string) {
 	return
 }
 
+// vmPortsPath returns the attribute the ports of the vm are configured with.
+func vmPortsPath(d *schema.ResourceData) string {
+	if len(d.Get("ports").([]interface{})) > 0 {
+		return "ports"
+	}
+	return "networks"
+}
+
 func resourceRustackVmCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("vdc_id: Error getting VDC: %s", err)
+		return apiErrorf("vdc_id: Error getting VDC: %s", err)
 	}
 
 	template, err := GetTemplateById(d, manager)
 	if err != nil {
-		return diag.Errorf("template_id: Error getting template: %s", err)
+		return apiErrorf("template_id: Error getting template: %s", err)
 	}
 
 	vmName := d.Get("name").(string)
 	cpu := d.Get("cpu").(int)
 	ram := d.Get("ram").(float64)
 	userData := d.Get("user_data").(string)
-	log.Printf(vmName, cpu, ram, userData, template.Name)
+	logDebug(ctx, subsystemVm, "Creating VM", map[string]interface{}{
+		"name":     vmName,
+		"cpu":      cpu,
+		"ram":      ram,
+		"template": template.Name,
+	})
 
 	// System disk creation
 	systemDiskArgs := d.Get("system_disk.0").(map[string]interface{})
@@ -87,7 +111,10 @@ func resourceRustackVmCreate(ctx context.Context, d *schema.ResourceData, meta i
 	for i, portId := range portsIds {
 		port, err := manager.GetPort(portId)
 		if err != nil {
-			return diag.FromErr(err)
+			return apiErrorDiag(err)
+		}
+		if port.Connected != nil {
+			return diag.FromErr(portOwnerError(vmPortsPath(d), port))
 		}
 		ports[i] = port
 	}
@@ -99,14 +126,16 @@ func resourceRustackVmCreate(ctx context.Context, d *schema.ResourceData, meta i
 
 	newVm := rustack.NewVm(vmName, cpu, ram, template, nil, &userData, ports,
 		systemDiskList, floatingIp)
-	newVm.Tags = unmarshalTagNames(d.Get("tags"))
+	newVm.Tags = expandTags(d, meta)
 
 	err = targetVdc.CreateVm(&newVm)
 	if err != nil {
-		return diag.Errorf("Error creating vm: %s", err)
+		return apiErrorf("Error creating vm: %s", err)
 	}
 
-	newVm.WaitLock()
+	if err := waitLock(ctx, manager, &newVm); err != nil {
+		return apiErrorDiag(err)
+	}
 	vm_power := d.Get("power").(bool)
 	if !vm_power {
 		newVm.PowerOff()
@@ -120,58 +149,84 @@ func resourceRustackVmCreate(ctx context.Context, d *schema.ResourceData, meta i
 		"storage_profile_id": newVm.Disks[0].StorageProfile.ID,
 	}
 
-	syncDisks(d, manager, targetVdc, &newVm)
-
 	d.Set("system_disk", systemDisk)
 	d.SetId(newVm.ID)
 
-	log.Printf("[INFO] VM created, ID: %s", d.Id())
+	if diags := syncDisks(ctx, d, manager, targetVdc, &newVm); diags.HasError() {
+		return diags
+	}
+
+	if diags := syncDataDisks(ctx, d, manager, targetVdc, &newVm); diags.HasError() {
+		return diags
+	}
+
+	logInfo(ctx, subsystemVm, "VM created", map[string]interface{}{"id": d.Id()})
 
 	return resourceRustackVmRead(ctx, d, meta)
 }
 
 func resourceRustackVmRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	vm, err := manager.GetVm(d.Id())
 	if err != nil {
-		if err.(*rustack.RustackApiError).Code() == 404 {
+		if isNotFound(err) {
 			d.SetId("")
 			return nil
 		} else {
-			return diag.Errorf("id: Error getting vm: %s", err)
+			return apiErrorf("id: Error getting vm: %s", err)
 		}
 	}
 
 	d.SetId(vm.ID)
 	d.Set("name", vm.Name)
+	if vm.Vdc != nil {
+		d.Set("vdc_id", vm.Vdc.ID)
+	}
 	d.Set("cpu", vm.Cpu)
 	d.Set("ram", vm.Ram)
-	d.Set("template_id", vm.Template.ID)
+	d.Set("hotadd_feature", vm.HotAdd)
+	if vm.Template != nil {
+		d.Set("template_id", vm.Template.ID)
+	}
+	if vm.UserData != nil {
+		d.Set("user_data", *vm.UserData)
+	}
 	d.Set("power", vm.Power)
 
-	flattenDisks := make([]string, len(vm.Disks)-1)
-	for i, disk := range vm.Disks {
-		if i == 0 {
-			systemDisk := make([]interface{}, 1)
-			systemDisk[0] = map[string]interface{}{
-				"id":                 disk.ID,
-				"name":               "Основной диск",
-				"size":               disk.Size,
-				"storage_profile_id": disk.StorageProfile.ID,
-				"external_id":        disk.ExternalID,
-			}
+	// after an import every disk of the vm is listed in disks, afterwards
+	// only the listed ones: the others belong to rustack_disk_attachment
+	imported := d.Get("system_disk.0.id").(string) == ""
+	listedDisks := d.Get("disks").(*schema.Set)
+	systemDisk, found, disks := flattenVmDisks(d.Get("system_disk.0.id").(string), vm.Disks)
+	if !found {
+		logWarn(ctx, subsystemVm, "System disk of the VM is missing", map[string]interface{}{
+			"id":             vm.ID,
+			"system_disk_id": d.Get("system_disk.0.id"),
+		})
+	}
+	d.Set("system_disk", systemDisk)
 
-			d.Set("system_disk", systemDisk)
-			continue
+	dataDisks := flattenVmDataDisks(d.Get("data_disk").([]interface{}), vm.Disks)
+	dataDiskIDs := vmDataDiskIds(dataDisks)
+	otherDisks := make([]string, 0, len(disks))
+	for _, diskID := range disks {
+		if !dataDiskIDs[diskID] && (imported || listedDisks.Contains(diskID)) {
+			otherDisks = append(otherDisks, diskID)
 		}
-		flattenDisks[i-1] = disk.ID
 	}
-	d.Set("disks", flattenDisks)
+	d.Set("data_disk", dataDisks)
+	d.Set("disks", otherDisks)
 
-	flattenPorts := make([]string, len(vm.Ports))
+	// ports follow disks: after an import every port is listed, afterwards
+	// only the listed ones, the others belong to rustack_port_attachment
+	listedPorts := vmListedPortIds(d.Get("ports"), d.Get("networks"))
+	flattenPorts := make([]string, 0, len(vm.Ports))
 	flattenNetworks := make([]map[string]interface{}, 0, len(vm.Ports))
-	for i, port := range vm.Ports {
-		flattenPorts[i] = port.ID
+	for _, port := range vm.Ports {
+		if !imported && !listedPorts[port.ID] {
+			continue
+		}
+		flattenPorts = append(flattenPorts, port.ID)
 		flattenNetworks = append(flattenNetworks, map[string]interface{}{
 			"id":         port.ID,
 			"ip_address": port.IpAddress,
@@ -185,16 +240,146 @@ func resourceRustackVmRead(ctx context.Context, d *schema.ResourceData, meta int
 	if vm.Floating != nil {
 		d.Set("floating_ip", vm.Floating.IpAddress)
 	}
-	d.Set("tags", marshalTagNames(vm.Tags))
+	if err := setTags(d, meta, vm.Tags); err != nil {
+		return apiErrorDiag(err)
+	}
 
 	return nil
 }
 
+// flattenVmDisks splits the disks of a vm into its system disk and the
+// other disks. The system disk is the one with systemDiskID, or the first
+// disk when it is not known yet, as after an import. When the vm has no such
+// disk, found is false and the system disk keeps only its id with a size of 0,
+// which customizeDiffVmSystemDisk turns into a replacement of the vm.
+func flattenVmDisks(systemDiskID string, vmDisks []*rustack.Disk) (systemDisk []interface{}, found bool, disks []string) {
+	systemDisk = []interface{}{map[string]interface{}{
+		"id":                 systemDiskID,
+		"name":               "",
+		"size":               0,
+		"storage_profile_id": "",
+		"external_id":        "",
+	}}
+	disks = make([]string, 0, len(vmDisks))
+	for i, disk := range vmDisks {
+		if disk.ID != systemDiskID && (systemDiskID != "" || i > 0) {
+			disks = append(disks, disk.ID)
+			continue
+		}
+		storageProfileID := ""
+		if disk.StorageProfile != nil {
+			storageProfileID = disk.StorageProfile.ID
+		}
+		systemDisk[0] = map[string]interface{}{
+			"id":                 disk.ID,
+			"name":               disk.Name,
+			"size":               disk.Size,
+			"storage_profile_id": storageProfileID,
+			"external_id":        disk.ExternalID,
+		}
+		found = true
+	}
+	return systemDisk, found, disks
+}
+
+// flattenVmDataDisks refreshes the data disks of the state from the disks of
+// the vm. A data disk that is no longer attached keeps its position with an
+// empty id and a size of 0, so that the next apply creates it again without
+// shifting the disks after it.
+func flattenVmDataDisks(dataDisks []interface{}, vmDisks []*rustack.Disk) []interface{} {
+	disksByID := make(map[string]*rustack.Disk, len(vmDisks))
+	for _, disk := range vmDisks {
+		disksByID[disk.ID] = disk
+	}
+
+	result := make([]interface{}, len(dataDisks))
+	for i, value := range dataDisks {
+		dataDisk := value.(map[string]interface{})
+		disk, ok := disksByID[dataDisk["id"].(string)]
+		if !ok {
+			result[i] = map[string]interface{}{
+				"id":                    "",
+				"name":                  dataDisk["name"],
+				"size":                  0,
+				"storage_profile_id":    dataDisk["storage_profile_id"],
+				"delete_on_termination": dataDisk["delete_on_termination"],
+			}
+			continue
+		}
+		storageProfileID := ""
+		if disk.StorageProfile != nil {
+			storageProfileID = disk.StorageProfile.ID
+		}
+		result[i] = map[string]interface{}{
+			"id":                    disk.ID,
+			"name":                  disk.Name,
+			"size":                  disk.Size,
+			"storage_profile_id":    storageProfileID,
+			"delete_on_termination": dataDisk["delete_on_termination"],
+		}
+	}
+	return result
+}
+
+func vmDataDiskIds(dataDisks []interface{}) map[string]bool {
+	ids := make(map[string]bool, len(dataDisks))
+	for _, value := range dataDisks {
+		if id := value.(map[string]interface{})["id"].(string); id != "" {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+// customizeDiffVmTemplate refuses a change of the template of an existing
+// vm at plan time, the platform can not reinstall a vm in place.
+func customizeDiffVmTemplate(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.HasChange("template_id") {
+		return nil
+	}
+	oldID, _ := d.GetChange("template_id")
+	if oldID.(string) == "" {
+		return nil
+	}
+	return fmt.Errorf("template_id: The template of an existing vm can not be changed, "+
+		"set it back to %s or replace the vm with terraform apply -replace", oldID)
+}
+
+// customizeDiffVmSystemDisk replaces a vm whose system disk has been detached
+// or deleted outside of terraform, it can not be attached again.
+func customizeDiffVmSystemDisk(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+	if oldSize, _ := d.GetChange("system_disk.0.size"); oldSize.(int) == 0 && d.HasChange("system_disk.0.size") {
+		return d.ForceNew("system_disk.0.size")
+	}
+	return nil
+}
+
+// customizeDiffVmDataDisks checks that the data_disk blocks have unique
+// names, syncDataDisks matches them with their disks by name.
+func customizeDiffVmDataDisks(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	names := make(map[string]int)
+	for i := range d.Get("data_disk").([]interface{}) {
+		path := fmt.Sprintf("data_disk.%d.name", i)
+		name, ok := knownString(d, path)
+		if !ok {
+			continue
+		}
+		if j, ok := names[name]; ok {
+			return fmt.Errorf("%s: Name %q is already used by data_disk.%d", path, name, j)
+		}
+		names[name] = i
+	}
+	return nil
+}
+
 func resourceRustackVmUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("vdc_id: Error getting VDC: %s", err)
+		return apiErrorf("vdc_id: Error getting VDC: %s", err)
 	}
 
 	hasFlavorChanged := false
@@ -202,7 +387,7 @@ func resourceRustackVmUpdate(ctx context.Context, d *schema.ResourceData, meta i
 
 	vm, err := manager.GetVm(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Error getting vm: %s", err)
+		return apiErrorf("id: Error getting vm: %s", err)
 	}
 
 	// Detect vm changes
@@ -218,8 +403,12 @@ func resourceRustackVmUpdate(ctx context.Context, d *schema.ResourceData, meta i
 		vm.Ram = d.Get("ram").(float64)
 	}
 
+	// hot add can only be switched while the vm is powered off
+	hasHotAddChanged := d.HasChange("hotadd_feature")
+	needUpdate = needUpdate || hasHotAddChanged
+
 	needPowerOn := false
-	if hasFlavorChanged && !vm.HotAdd && vm.Power {
+	if (hasHotAddChanged || hasFlavorChanged && !vm.HotAdd) && vm.Power {
 		vm.PowerOff()
 		needPowerOn = true
 	}
@@ -233,14 +422,18 @@ func resourceRustackVmUpdate(ctx context.Context, d *schema.ResourceData, meta i
 		}
 		d.Set("floating", vm.Floating != nil)
 	}
-	if d.HasChange("tags") {
+	if d.HasChange("tags_all") {
 		needUpdate = true
-		vm.Tags = unmarshalTagNames(d.Get("tags"))
+		vm.Tags = expandTags(d, meta)
+	}
+
+	if hasHotAddChanged {
+		vm.HotAdd = d.Get("hotadd_feature").(bool)
 	}
 
 	if needUpdate {
-		if err := repeatOnError(vm.Update, vm); err != nil {
-			return diag.Errorf("Error updating vm: %s", err)
+		if err := runUnlocked(ctx, manager, vm.Update, vm); err != nil {
+			return apiErrorf("Error updating vm: %s", err)
 		}
 	}
 
@@ -255,11 +448,15 @@ func resourceRustackVmUpdate(ctx context.Context, d *schema.ResourceData, meta i
 		}
 	}
 
-	if diags := syncDisks(d, manager, targetVdc, vm); diags.HasError() {
+	if diags := syncDataDisks(ctx, d, manager, targetVdc, vm); diags.HasError() {
+		return diags
+	}
+
+	if diags := syncDisks(ctx, d, manager, targetVdc, vm); diags.HasError() {
 		return diags
 	}
 
-	if diags := syncPorts(d, manager, targetVdc, vm); diags.HasError() {
+	if diags := syncPorts(ctx, d, manager, targetVdc, vm); diags.HasError() {
 		return diags
 	}
 
@@ -267,27 +464,42 @@ func resourceRustackVmUpdate(ctx context.Context, d *schema.ResourceData, meta i
 }
 
 func resourceRustackVmDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	manager := meta.(*CombinedConfig).rustackManager()
+	manager := meta.(*CombinedConfig).rustackManager().WithContext(ctx)
 	vm, err := manager.GetVm(d.Id())
 	if err != nil {
-		return diag.Errorf("id: Error getting vm: %s", err)
+		return apiErrorf("id: Error getting vm: %s", err)
 	}
 
 	vm.Floating = &rustack.Port{IpAddress: nil}
-	if err := repeatOnError(vm.Update, vm); err != nil {
-		return diag.Errorf("Error updating vm: %s", err)
+	if err := runUnlocked(ctx, manager, vm.Update, vm); err != nil {
+		return apiErrorf("Error updating vm: %s", err)
+	}
+	if err := waitLock(ctx, manager, vm); err != nil {
+		return apiErrorDiag(err)
 	}
-	vm.WaitLock()
 
 	disksIds := d.Get("disks").(*schema.Set).List()
 	for _, diskId := range disksIds {
 		disk, err := manager.GetDisk(diskId.(string))
 		if err != nil {
-			return diag.FromErr(err)
+			if isNotFound(err) {
+				continue
+			}
+			return apiErrorDiag(err)
+		}
+		// disks attached with rustack_disk_attachment may have moved already
+		if disk.Vm == nil || disk.Vm.ID != vm.ID {
+			continue
 		}
 		err = vm.DetachDisk(disk)
 		if err != nil {
-			return diag.FromErr(err)
+			return apiErrorDiag(err)
+		}
+	}
+
+	for i, dataDisk := range d.Get("data_disk").([]interface{}) {
+		if err := removeDataDisk(ctx, manager, vm, dataDisk.(map[string]interface{})); err != nil {
+			return apiErrorf("data_disk.%d: Error removing disk: %s", i, err)
 		}
 	}
 
@@ -295,38 +507,48 @@ func resourceRustackVmDelete(ctx context.Context, d *schema.ResourceData, meta i
 	for _, portId := range portsIds {
 		port, err := manager.GetPort(portId)
 		if err != nil {
-			return diag.FromErr(err)
+			if isNotFound(err) {
+				continue
+			}
+			return apiErrorDiag(err)
+		}
+		// ports connected with rustack_port_attachment may have moved already
+		if port.Connected == nil || port.Connected.ID != vm.ID {
+			continue
 		}
 		if err := vm.DisconnectPort(port); err != nil {
-			return diag.FromErr(err)
+			return apiErrorDiag(err)
 		}
 	}
-	vm.WaitLock()
+	if err := waitLock(ctx, manager, vm); err != nil {
+		return apiErrorDiag(err)
+	}
 
 	err = vm.Delete()
 	if err != nil {
-		return diag.Errorf("Error deleting vm: %s", err)
+		return apiErrorf("Error deleting vm: %s", err)
+	}
+	if err := waitLock(ctx, manager, vm); err != nil {
+		return apiErrorDiag(err)
 	}
-	vm.WaitLock()
 
 	return nil
 }
 
-func syncDisks(d *schema.ResourceData, manager *rustack.Manager, vdc *rustack.Vdc, vm *rustack.Vm) (diagErr diag.Diagnostics) {
+func syncDisks(ctx context.Context, d *schema.ResourceData, manager *rustack.Manager, vdc *rustack.Vdc, vm *rustack.Vm) (diagErr diag.Diagnostics) {
 	targetVdc, err := GetVdcById(d, manager)
 	if err != nil {
-		return diag.Errorf("vdc_id: Error getting VDC: %s", err)
+		return apiErrorf("vdc_id: Error getting VDC: %s", err)
 	}
 
-	// Which disks are present on vm and not mentioned in the state?
-	// Detach disks
-	diagErr = detachOldDisk(d, manager, vm)
+	// Detach disks removed from the state
+	diagErr = detachOldDisk(ctx, d, manager, vm)
 	if diagErr != nil {
 		return
 	}
 
 	// List disks to join
-	diagErr = attachNewDisk(d, manager, vm)
+	diagErr = attachNewDisk(ctx, d, manager, vm)
 	if diagErr != nil {
 		return
 	}
@@ -338,11 +560,11 @@ func syncDisks(d *schema.ResourceData, manager *rustack.Manager, vdc *rustack.Vd
 		diskSize := systemDiskArgs["size"].(int)
 		systemDisk, err := manager.GetDisk(systemDiskId)
 		if err != nil {
-			return diag.Errorf("system_disk: Error getting system disk id: %s", err)
+			return apiErrorf("system_disk: Error getting system disk id: %s", err)
 		}
 
 		if err = systemDisk.Resize(diskSize); err != nil {
-			return diag.Errorf("size: Error resizing disk: %s", err)
+			return apiErrorf("size: Error resizing disk: %s", err)
 		}
 
 		if !d.HasChange("system_disk.0.storage_profile_id") {
@@ -352,28 +574,164 @@ func syncDisks(d *schema.ResourceData, manager *rustack.Manager, vdc *rustack.Vd
 		storageProfileId := d.Get("system_disk.0.storage_profile_id").(string)
 		storageProfile, err := targetVdc.GetStorageProfile(storageProfileId)
 		if err != nil {
-			return diag.Errorf("storage_profile_id: Error getting storage profile: %s", err)
+			return apiErrorf("storage_profile_id: Error getting storage profile: %s", err)
 		}
 
 		err = systemDisk.UpdateStorageProfile(*storageProfile)
 		if err != nil {
-			return diag.Errorf("Error updating storage: %s", err)
+			return apiErrorf("Error updating storage: %s", err)
 		}
 	}
 
 	return
 }
 
-func syncPorts(d *schema.ResourceData, manager *rustack.Manager, vdc *rustack.Vdc, vm *rustack.Vm) (diagErr diag.Diagnostics) {
+// syncDataDisks creates, updates and removes the disks of the data_disk
+// blocks. Blocks are matched with the disks of the prior state by their name,
+// so removing or reordering blocks does not touch the disks of the others. A
+// renamed block keeps the disk of the unmatched block at its position, a
+// block without a disk gets a new one.
+func syncDataDisks(ctx context.Context, d *schema.ResourceData, manager *rustack.Manager, vdc *rustack.Vdc, vm *rustack.Vm) diag.Diagnostics {
+	if !d.HasChange("data_disk") {
+		return nil
+	}
+	oldValue, newValue := d.GetChange("data_disk")
+	oldDisks := oldValue.([]interface{})
+	newDisks := newValue.([]interface{})
+
+	matches := matchDataDisks(oldDisks, newDisks)
+	matched := make(map[int]bool, len(matches))
+	for _, j := range matches {
+		matched[j] = true
+	}
+	for i, value := range oldDisks {
+		if matched[i] {
+			continue
+		}
+		if err := removeDataDisk(ctx, manager, vm, value.(map[string]interface{})); err != nil {
+			return apiErrorf("data_disk.%d: Error removing disk: %s", i, err)
+		}
+	}
+
+	for i, value := range newDisks {
+		dataDisk := value.(map[string]interface{})
+		path := fmt.Sprintf("data_disk.%d", i)
+		storageProfile, err := GetStorageProfileById(dataDisk["storage_profile_id"].(string), manager, vdc)
+		if err != nil {
+			return apiErrorf("%s.storage_profile_id: Error getting storage profile: %s", path, err)
+		}
+
+		oldDisk := map[string]interface{}{"id": ""}
+		if j, ok := matches[i]; ok {
+			oldDisk = oldDisks[j].(map[string]interface{})
+		}
+		if oldDisk["id"] == "" {
+			newDisk := rustack.NewDisk(dataDisk["name"].(string), dataDisk["size"].(int), storageProfile)
+			newDisk.Vm = vm
+			if err := runUnlocked(ctx, manager, func() error { return vdc.CreateDisk(&newDisk) }, vm); err != nil {
+				return apiErrorf("%s: Error creating disk: %s", path, err)
+			}
+			if err := waitLock(ctx, manager, &newDisk); err != nil {
+				return apiErrorDiag(err)
+			}
+			logInfo(ctx, subsystemVm, "Data disk created", map[string]interface{}{"disk_id": newDisk.ID})
+			dataDisk["id"] = newDisk.ID
+			continue
+		}
+
+		dataDisk["id"] = oldDisk["id"]
+		if dataDisk["name"] == oldDisk["name"] && dataDisk["size"] == oldDisk["size"] &&
+			dataDisk["storage_profile_id"] == oldDisk["storage_profile_id"] {
+			continue
+		}
+		disk, err := manager.GetDisk(oldDisk["id"].(string))
+		if err != nil {
+			return apiErrorf("%s.id: Error getting disk: %s", path, err)
+		}
+		disk.Name = dataDisk["name"].(string)
+		disk.Size = dataDisk["size"].(int)
+		disk.StorageProfile = storageProfile
+		if err := runUnlocked(ctx, manager, disk.Update, disk); err != nil {
+			return apiErrorf("%s: Error updating disk: %s", path, err)
+		}
+	}
+
+	if err := d.Set("data_disk", newDisks); err != nil {
+		return diag.FromErr(err)
+	}
+	// the disks of the vm are synced next and must not see removed disks
+	if err := vm.Reload(); err != nil {
+		return apiErrorDiag(err)
+	}
+	return nil
+}
+
+// matchDataDisks pairs the planned data_disk blocks with the blocks of the
+// prior state, first by name and then by position for renamed blocks. It
+// returns the index of the old block for each matched new one.
+func matchDataDisks(oldDisks, newDisks []interface{}) map[int]int {
+	oldByName := make(map[string]int, len(oldDisks))
+	for j, value := range oldDisks {
+		oldByName[value.(map[string]interface{})["name"].(string)] = j
+	}
+
+	matches := make(map[int]int, len(newDisks))
+	matched := make(map[int]bool, len(oldDisks))
+	for i, value := range newDisks {
+		if j, ok := oldByName[value.(map[string]interface{})["name"].(string)]; ok && !matched[j] {
+			matches[i] = j
+			matched[j] = true
+		}
+	}
+	for i := range newDisks {
+		if _, ok := matches[i]; !ok && i < len(oldDisks) && !matched[i] {
+			matches[i] = i
+			matched[i] = true
+		}
+	}
+	return matches
+}
+
+// removeDataDisk detaches the disk of a data_disk block from the vm and
+// deletes it, unless delete_on_termination is off.
+func removeDataDisk(ctx context.Context, manager *rustack.Manager, vm *rustack.Vm, dataDisk map[string]interface{}) error {
+	diskID := dataDisk["id"].(string)
+	if diskID == "" {
+		return nil
+	}
+	disk, err := manager.GetDisk(diskID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if disk.Vm != nil && disk.Vm.ID == vm.ID {
+		if err := runUnlocked(ctx, manager, func() error { return vm.DetachDisk(disk) }, vm); err != nil {
+			return err
+		}
+		if err := waitLock(ctx, manager, disk); err != nil {
+			return err
+		}
+	}
+	if !dataDisk["delete_on_termination"].(bool) {
+		return nil
+	}
+	logInfo(ctx, subsystemVm, "Deleting data disk", map[string]interface{}{"disk_id": disk.ID})
+	return disk.Delete()
+}
+
+func syncPorts(ctx context.Context, d *schema.ResourceData, manager *rustack.Manager, vdc *rustack.Vdc, vm *rustack.Vm) (diagErr diag.Diagnostics) {
 
 	// Delete ConnectNewPort ports and create a new if connected
-	diagErr = DisconnectOldPort(d, manager, vm)
+	diagErr = DisconnectOldPort(ctx, d, manager, vm)
 	if diagErr != nil {
 		return
 
 	}
 
-	diagErr = ConnectNewPort(d, manager, vm)
+	diagErr = ConnectNewPort(ctx, d, manager, vm)
 	if diagErr != nil {
 		return
 	}
@@ -381,7 +739,7 @@ func syncPorts(d *schema.ResourceData, manager *rustack.Manager, vdc *rustack.Vd
 	return
 }
 
-func ConnectNewPort(d *schema.ResourceData, manager *rustack.Manager, vm *rustack.Vm) (diagErr diag.Diagnostics) {
+func ConnectNewPort(ctx context.Context, d *schema.ResourceData, manager *rustack.Manager, vm *rustack.Vm) (diagErr diag.Diagnostics) {
 	portsIds := getVmPortsIds(d)
 	for _, portId := range portsIds {
 		found := false
@@ -396,20 +754,16 @@ func ConnectNewPort(d *schema.ResourceData, manager *rustack.Manager, vm *rustac
 			port, err := manager.GetPort(portId)
 
 			if err != nil {
-				diagErr = diag.FromErr(err)
+				diagErr = apiErrorDiag(err)
 				return
 			}
 			if port.Connected != nil && port.Connected.ID != vm.ID {
-
-				if err := vm.DisconnectPort(port); err != nil {
-					return diag.FromErr(err)
-				}
-				vm.WaitLock()
+				return diag.FromErr(portOwnerError(vmPortsPath(d), port))
 			}
-			log.Printf("Port `%s` will be Attached", port.ID)
+			logInfo(ctx, subsystemVm, "Attaching port", map[string]interface{}{"port_id": port.ID})
 
 			if err := vm.ConnectPort(port, true); err != nil {
-				diagErr = diag.Errorf("Ports: Error Cannot attach port `%s`: %s", port.ID, err)
+				diagErr = apiErrorf("Ports: Error Cannot attach port `%s`: %s", port.ID, err)
 				return
 			}
 		}
@@ -417,25 +771,29 @@ func ConnectNewPort(d *schema.ResourceData, manager *rustack.Manager, vm *rustac
 	return
 }
 
-func DisconnectOldPort(d *schema.ResourceData, manager *rustack.Manager, vm *rustack.Vm) diag.Diagnostics {
-	portsIds := getVmPortsIds(d)
+// DisconnectOldPort disconnects the ports removed from networks or ports.
+// Other ports of the vm are left alone, they belong to
+// rustack_port_attachment.
+func DisconnectOldPort(ctx context.Context, d *schema.ResourceData, manager *rustack.Manager, vm *rustack.Vm) diag.Diagnostics {
+	oldPorts, _ := d.GetChange("ports")
+	oldNetworks, _ := d.GetChange("networks")
+	removed := vmListedPortIds(oldPorts, oldNetworks)
+	for _, portId := range getVmPortsIds(d) {
+		delete(removed, portId)
+	}
+
 	for _, port := range vm.Ports {
-		found := false
-		for _, portId := range portsIds {
-			if portId == port.ID {
-				found = true
-				break
-			}
+		if !removed[port.ID] {
+			continue
 		}
-		if !found {
-			if port.Connected != nil && port.Connected.ID == vm.ID {
-				log.Printf("Port %s found on vm and not mentioned in the state."+
-					" Port will be detached", port.ID)
+		if port.Connected != nil && port.Connected.ID == vm.ID {
+			logInfo(ctx, subsystemVm, "Port was removed from networks, detaching it", map[string]interface{}{"port_id": port.ID})
 
-				if err := vm.DisconnectPort(port); err != nil {
-					return diag.FromErr(err)
-				}
-				vm.WaitLock()
+			if err := vm.DisconnectPort(port); err != nil {
+				return apiErrorDiag(err)
+			}
+			if err := waitLock(ctx, manager, vm); err != nil {
+				return apiErrorDiag(err)
 			}
 		}
 	}
@@ -443,7 +801,20 @@ func DisconnectOldPort(d *schema.ResourceData, manager *rustack.Manager, vm *rus
 	return nil
 }
 
-func attachNewDisk(d *schema.ResourceData, manager *rustack.Manager, vm *rustack.Vm) (diagErr diag.Diagnostics) {
+// vmListedPortIds returns the ids of the ports listed in the given values of
+// ports and networks.
+func vmListedPortIds(ports, networks interface{}) map[string]bool {
+	ids := make(map[string]bool)
+	for _, portId := range ports.([]interface{}) {
+		ids[portId.(string)] = true
+	}
+	for _, network := range networks.([]interface{}) {
+		ids[network.(map[string]interface{})["id"].(string)] = true
+	}
+	return ids
+}
+
+func attachNewDisk(ctx context.Context, d *schema.ResourceData, manager *rustack.Manager, vm *rustack.Vm) (diagErr diag.Diagnostics) {
 	disksIds := d.Get("disks").(*schema.Set).List()
 	// Save system_disk
 	systemDiskResource := d.Get("system_disk.0")
@@ -464,20 +835,22 @@ func attachNewDisk(d *schema.ResourceData, manager *rustack.Manager, vm *rustack
 		if !found {
 			disk, err := manager.GetDisk(diskId.(string))
 			if err != nil {
-				diagErr = diag.FromErr(err)
+				diagErr = apiErrorDiag(err)
 				return
 			}
 			if disk.Vm != nil && disk.Vm.ID != vm_id {
-				log.Printf("Disk %s found on other vm and will be detached for attached to vm.", disk.ID)
-				vm.DetachDisk(disk)
-				if err := vm.Reload(); err != nil {
-					return diag.FromErr(err)
+				if !d.Get("force_detach").(bool) {
+					return diag.Errorf("disks: Disk %q is attached to vm %q (%s), set force_detach = true to move it",
+						disk.Name, disk.Vm.Name, disk.Vm.ID)
+				}
+				logInfo(ctx, subsystemVm, "Disk is attached to another VM, detaching it", map[string]interface{}{"disk_id": disk.ID})
+				if err := detachDisk(ctx, manager, disk); err != nil {
+					return apiErrorf("disks: Error detaching disk %s from vm %s: %s", disk.ID, disk.Vm.ID, err)
 				}
-				vm.WaitLock()
 			}
-			log.Printf("Disk `%s` will be Attached", disk.ID)
+			logInfo(ctx, subsystemVm, "Attaching disk", map[string]interface{}{"disk_id": disk.ID})
 			if err = vm.AttachDisk(disk); err != nil {
-				diagErr = diag.Errorf("ERROR. Cannot attach disk `%s`: %s", disk.ID, err)
+				diagErr = apiErrorf("ERROR. Cannot attach disk `%s`: %s", disk.ID, err)
 				return
 			}
 			needReload = true
@@ -486,48 +859,41 @@ func attachNewDisk(d *schema.ResourceData, manager *rustack.Manager, vm *rustack
 
 	if needReload {
 		if err := vm.Reload(); err != nil {
-			return diag.FromErr(err)
+			return apiErrorDiag(err)
 		}
 	}
 
 	return
 }
 
-func detachOldDisk(d *schema.ResourceData, manager *rustack.Manager, vm *rustack.Vm) (diagErr diag.Diagnostics) {
-	disksIds := d.Get("disks").(*schema.Set).List()
-	systemDiskResource := d.Get("system_disk.0")
-	systemDisk := systemDiskResource.(map[string]interface{})["id"].(string)
+// detachOldDisk detaches the disks removed from disks. Other disks of the
+// vm are left alone, they belong to data_disk or rustack_disk_attachment.
+func detachOldDisk(ctx context.Context, d *schema.ResourceData, manager *rustack.Manager, vm *rustack.Vm) (diagErr diag.Diagnostics) {
+	oldValue, newValue := d.GetChange("disks")
+	removed := oldValue.(*schema.Set).Difference(newValue.(*schema.Set)).List()
 	var needReload bool
-	disksIds = append(disksIds, systemDisk)
-	vm_id := vm.ID
 
-	for _, disk := range vm.Disks {
-		found := false
-		for _, diskId := range disksIds {
-			if diskId == disk.ID {
-				found = true
-				break
+	for _, diskId := range removed {
+		disk, err := manager.GetDisk(diskId.(string))
+		if err != nil {
+			if isNotFound(err) {
+				continue
 			}
+			return apiErrorDiag(err)
 		}
-
-		if !found {
-			disk, err := manager.GetDisk(disk.ID)
-			if err != nil {
-				diagErr = diag.FromErr(err)
-				return
-			}
-			if disk.Vm != nil && disk.Vm.ID == vm_id {
-				log.Printf("Disk %s found on vm and not mentioned in the state."+
-					" Disk will be detached", disk.ID)
-				vm.DetachDisk(disk)
-				needReload = true
-			}
+		if disk.Vm == nil || disk.Vm.ID != vm.ID {
+			continue
+		}
+		logInfo(ctx, subsystemVm, "Disk was removed from disks, detaching it", map[string]interface{}{"disk_id": disk.ID})
+		if err := runUnlocked(ctx, manager, func() error { return vm.DetachDisk(disk) }, vm); err != nil {
+			return apiErrorf("disks: Error detaching disk %s: %s", disk.ID, err)
 		}
+		needReload = true
 	}
 
 	if needReload {
 		if err := vm.Reload(); err != nil {
-			return diag.FromErr(err)
+			return apiErrorDiag(err)
 		}
 	}
 
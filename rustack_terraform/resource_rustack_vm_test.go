@@ -0,0 +1,567 @@
+package rustack_terraform
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/rustack-cloud-platform/rcp-go/rustack"
+)
+
+func TestAccRustackVm_basic(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_vm", "vm"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackVmConfig(api, "terraform-acc", 1, 1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckExists(api, "rustack_vm.test", "vm"),
+					resource.TestCheckResourceAttr("rustack_vm.test", "name", "terraform-acc"),
+					resource.TestCheckResourceAttr("rustack_vm.test", "cpu", "1"),
+					resource.TestCheckResourceAttr("rustack_vm.test", "ram", "1"),
+					resource.TestCheckResourceAttr("rustack_vm.test", "template_id", api.TemplateID),
+					resource.TestCheckResourceAttr("rustack_vm.test", "system_disk.0.size", "10"),
+					resource.TestCheckResourceAttr("rustack_vm.test", "disks.#", "1"),
+					resource.TestCheckResourceAttr("rustack_vm.test", "networks.#", "1"),
+					resource.TestCheckResourceAttrPair("rustack_vm.test", "networks.0.id", "rustack_port.test", "id"),
+					resource.TestCheckResourceAttr("rustack_vm.test", "floating", "true"),
+					resource.TestCheckResourceAttrSet("rustack_vm.test", "floating_ip"),
+				),
+			},
+			{
+				Config: testAccRustackVmConfig(api, "terraform-acc-resized", 2, 2),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_vm.test", "name", "terraform-acc-resized"),
+					resource.TestCheckResourceAttr("rustack_vm.test", "cpu", "2"),
+					resource.TestCheckResourceAttr("rustack_vm.test", "ram", "2"),
+				),
+			},
+			{
+				ResourceName:            "rustack_vm.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"force_detach"},
+			},
+		},
+	})
+}
+
+func TestAccRustackVm_drift(t *testing.T) {
+	api := newFakeRustackAPI(t)
+	var vmID string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_vm", "vm"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackVmConfig(api, "terraform-acc", 1, 1),
+				Check: resource.TestCheckResourceAttrWith("rustack_vm.test", "id", func(id string) error {
+					vmID = id
+					return nil
+				}),
+			},
+			{
+				PreConfig: func() {
+					api.Update("vm", vmID, map[string]interface{}{
+						"name":           "renamed",
+						"cpu":            4,
+						"hotadd_feature": true,
+						"user_data":      "#cloud-config\r\n",
+					})
+					api.Update("disk", api.Find("disk", "terraform-acc-data"), map[string]interface{}{"vm": nil})
+				},
+				Config:             testAccRustackVmConfig(api, "terraform-acc", 1, 1),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: testAccRustackVmConfig(api, "terraform-acc", 1, 1),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_vm.test", "name", "terraform-acc"),
+					resource.TestCheckResourceAttr("rustack_vm.test", "cpu", "1"),
+					resource.TestCheckResourceAttr("rustack_vm.test", "hotadd_feature", "true"),
+					resource.TestCheckResourceAttr("rustack_vm.test", "disks.#", "1"),
+					testAccCheckDiskAttachedTo(api, "rustack_disk.test", "rustack_vm.test"),
+					resource.TestCheckResourceAttrWith("rustack_vm.test", "id", func(id string) error {
+						if id != vmID {
+							return fmt.Errorf("expected the vm %s to be updated in place, got %s", vmID, id)
+						}
+						return nil
+					}),
+				),
+			},
+			{
+				Config:      strings.Replace(testAccRustackVmConfig(api, "terraform-acc", 1, 1), api.TemplateID, "other-template", 1),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`template_id: The template of an existing vm can not be changed`),
+			},
+			{
+				PreConfig: func() {
+					api.Update("disk", api.Find("disk", "Основной диск"), map[string]interface{}{"vm": nil})
+				},
+				Config: testAccRustackVmConfig(api, "terraform-acc", 1, 1),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_vm.test", "system_disk.0.size", "10"),
+					resource.TestCheckResourceAttr("rustack_vm.test", "disks.#", "1"),
+					resource.TestCheckResourceAttrWith("rustack_vm.test", "id", func(id string) error {
+						if id == vmID {
+							return fmt.Errorf("expected the vm %s without its system disk to be replaced", id)
+						}
+						return nil
+					}),
+				),
+			},
+		},
+	})
+}
+
+func TestAccRustackVm_disks(t *testing.T) {
+	api := newFakeRustackAPI(t)
+	withoutDisks := strings.Replace(testAccRustackVmConfig(api, "terraform-acc", 1, 1),
+		"disks = [rustack_disk.test.id]", "disks = []", 1)
+	checkDataDisk := func(attached bool) resource.TestCheckFunc {
+		return func(*terraform.State) error {
+			return testAccCheckFakeDisk(api, api.Find("disk", "terraform-acc-data"), "terraform-acc-data", 5, attached)
+		}
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_vm", "vm"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackVmConfig(api, "terraform-acc", 1, 1),
+				Check:  checkDataDisk(true),
+			},
+			{
+				Config: withoutDisks,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_vm.test", "disks.#", "0"),
+					checkDataDisk(false),
+				),
+			},
+			{
+				Config: testAccRustackVmConfig(api, "terraform-acc", 1, 1) + testAccRustackVmOtherConfig(api, `
+  disks      = [rustack_disk.test.id]
+  depends_on = [rustack_vm.test]
+`),
+				ExpectError: regexp.MustCompile(`disks: Disk "terraform-acc-data" is attached to vm "terraform-acc" .* set force_detach = true to move it`),
+			},
+			{
+				Config: withoutDisks + testAccRustackVmOtherConfig(api, `
+  networks {
+    id = rustack_port.test.id
+  }
+
+  depends_on = [rustack_vm.test]
+`),
+				ExpectError: regexp.MustCompile(`networks: Port .* is connected to vm .*, disconnect it there before attaching it`),
+			},
+			{
+				Config: withoutDisks + testAccRustackVmOtherConfig(api, `
+  disks        = [rustack_disk.test.id]
+  force_detach = true
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_vm.test", "disks.#", "0"),
+					resource.TestCheckResourceAttr("rustack_vm.other", "disks.#", "1"),
+					testAccCheckDiskAttachedTo(api, "rustack_disk.test", "rustack_vm.other"),
+				),
+			},
+		},
+	})
+}
+
+// testAccRustackVmOtherConfig adds a second vm to testAccRustackVmConfig.
+func testAccRustackVmOtherConfig(api *fakeRustackAPI, extra string) string {
+	return fmt.Sprintf(`
+resource "rustack_port" "other" {
+  vdc_id     = rustack_vdc.test.id
+  network_id = rustack_vdc.test.default_network_id
+}
+
+resource "rustack_vm" "other" {
+  vdc_id      = rustack_vdc.test.id
+  name        = "terraform-acc-other"
+  cpu         = 1
+  ram         = 1
+  template_id = %[2]q
+  user_data   = "#cloud-config"
+
+  system_disk {
+    size               = 10
+    storage_profile_id = %[1]q
+  }
+
+  networks {
+    id = rustack_port.other.id
+  }
+`+extra+`}
+`, api.StorageProfileID, api.TemplateID)
+}
+
+func TestAccRustackVm_dataDisks(t *testing.T) {
+	api := newFakeRustackAPI(t)
+	var firstDiskID string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy: resource.ComposeTestCheckFunc(
+			testAccCheckDestroyed(api, "rustack_vm", "vm"),
+			func(*terraform.State) error {
+				if api.Exists("disk", firstDiskID) {
+					return fmt.Errorf("data disk %s still exists", firstDiskID)
+				}
+				return nil
+			},
+		),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackVmDataDiskConfig(api, `
+  data_disk {
+    name               = "terraform-acc-a"
+    size               = 5
+    storage_profile_id = %[1]q
+  }
+
+  data_disk {
+    name                  = "terraform-acc-b"
+    size                  = 6
+    storage_profile_id    = %[1]q
+    delete_on_termination = false
+  }
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_vm.test", "data_disk.#", "2"),
+					resource.TestCheckResourceAttr("rustack_vm.test", "data_disk.0.size", "5"),
+					resource.TestCheckResourceAttr("rustack_vm.test", "data_disk.1.name", "terraform-acc-b"),
+					resource.TestCheckResourceAttr("rustack_vm.test", "disks.#", "0"),
+					resource.TestCheckResourceAttrWith("rustack_vm.test", "data_disk.0.id", func(id string) error {
+						firstDiskID = id
+						return testAccCheckFakeDisk(api, id, "terraform-acc-a", 5, true)
+					}),
+				),
+			},
+			{
+				Config: testAccRustackVmDataDiskConfig(api, `
+  data_disk {
+    name               = "terraform-acc-a"
+    size               = 8
+    storage_profile_id = %[1]q
+  }
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_vm.test", "data_disk.#", "1"),
+					resource.TestCheckResourceAttr("rustack_vm.test", "data_disk.0.size", "8"),
+					resource.TestCheckResourceAttrWith("rustack_vm.test", "data_disk.0.id", func(id string) error {
+						if id != firstDiskID {
+							return fmt.Errorf("expected the disk %s to be resized in place, got %s", firstDiskID, id)
+						}
+						return testAccCheckFakeDisk(api, id, "terraform-acc-a", 8, true)
+					}),
+					func(*terraform.State) error {
+						return testAccCheckFakeDisk(api, api.Find("disk", "terraform-acc-b"), "terraform-acc-b", 6, false)
+					},
+				),
+			},
+			{
+				PreConfig: func() { api.Update("disk", firstDiskID, map[string]interface{}{"vm": nil}) },
+				Config: testAccRustackVmDataDiskConfig(api, `
+  data_disk {
+    name               = "terraform-acc-a"
+    size               = 8
+    storage_profile_id = %[1]q
+  }
+`),
+				Check: resource.TestCheckResourceAttrWith("rustack_vm.test", "data_disk.0.id", func(id string) error {
+					if id == firstDiskID {
+						return fmt.Errorf("expected the detached disk %s to be replaced", id)
+					}
+					firstDiskID = id
+					return testAccCheckFakeDisk(api, id, "terraform-acc-a", 8, true)
+				}),
+			},
+			{
+				Config: testAccRustackVmDataDiskConfig(api, `
+  data_disk {
+    name               = "terraform-acc-a"
+    size               = 8
+    storage_profile_id = "missing"
+  }
+`),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`data_disk.0.storage_profile_id: Storage profile missing is not available`),
+			},
+		},
+	})
+}
+
+func TestAccRustackVm_dataDiskRemoveMiddle(t *testing.T) {
+	api := newFakeRustackAPI(t)
+	diskIDs := map[string]string{}
+	captureDisk := func(name string, size int) resource.CheckResourceAttrWithFunc {
+		return func(id string) error {
+			diskIDs[name] = id
+			return testAccCheckFakeDisk(api, id, name, size, true)
+		}
+	}
+	sameDisk := func(name string, size int) resource.CheckResourceAttrWithFunc {
+		return func(id string) error {
+			if id != diskIDs[name] {
+				return fmt.Errorf("expected the disk %s of %s to be kept, got %s", diskIDs[name], name, id)
+			}
+			return testAccCheckFakeDisk(api, id, name, size, true)
+		}
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroyed(api, "rustack_vm", "vm"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccRustackVmDataDiskConfig(api, `
+  data_disk {
+    name               = "terraform-acc-a"
+    size               = 5
+    storage_profile_id = %[1]q
+  }
+
+  data_disk {
+    name               = "terraform-acc-b"
+    size               = 6
+    storage_profile_id = %[1]q
+  }
+
+  data_disk {
+    name               = "terraform-acc-c"
+    size               = 7
+    storage_profile_id = %[1]q
+  }
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrWith("rustack_vm.test", "data_disk.0.id", captureDisk("terraform-acc-a", 5)),
+					resource.TestCheckResourceAttrWith("rustack_vm.test", "data_disk.1.id", captureDisk("terraform-acc-b", 6)),
+					resource.TestCheckResourceAttrWith("rustack_vm.test", "data_disk.2.id", captureDisk("terraform-acc-c", 7)),
+				),
+			},
+			{
+				Config: testAccRustackVmDataDiskConfig(api, `
+  data_disk {
+    name               = "terraform-acc-a"
+    size               = 5
+    storage_profile_id = %[1]q
+  }
+
+  data_disk {
+    name               = "terraform-acc-c"
+    size               = 7
+    storage_profile_id = %[1]q
+  }
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("rustack_vm.test", "data_disk.#", "2"),
+					resource.TestCheckResourceAttrWith("rustack_vm.test", "data_disk.0.id", sameDisk("terraform-acc-a", 5)),
+					resource.TestCheckResourceAttrWith("rustack_vm.test", "data_disk.1.id", sameDisk("terraform-acc-c", 7)),
+					func(*terraform.State) error {
+						if api.Exists("disk", diskIDs["terraform-acc-b"]) {
+							return fmt.Errorf("removed disk %s still exists", diskIDs["terraform-acc-b"])
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config: testAccRustackVmDataDiskConfig(api, `
+  data_disk {
+    name               = "terraform-acc-a"
+    size               = 5
+    storage_profile_id = %[1]q
+  }
+
+  data_disk {
+    name               = "terraform-acc-a"
+    size               = 7
+    storage_profile_id = %[1]q
+  }
+`),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`data_disk.1.name: Name "terraform-acc-a" is already used by data_disk.0`),
+			},
+		},
+	})
+}
+
+// testAccCheckFakeDisk checks the size of a disk in the fake API and whether
+// it is attached to a vm.
+func testAccCheckFakeDisk(api *fakeRustackAPI, id, name string, size int, attached bool) error {
+	disk := api.Object("disk", id)
+	if disk == nil {
+		return fmt.Errorf("disk %s not found", id)
+	}
+	if disk["name"] != name || fakeInt(disk["size"]) != size {
+		return fmt.Errorf("disk %s is %v with %v GB, want %s with %d GB", id, disk["name"], disk["size"], name, size)
+	}
+	if isAttached := disk["vm"] != nil; isAttached != attached {
+		return fmt.Errorf("disk %s attached = %v, want %v", id, isAttached, attached)
+	}
+	return nil
+}
+
+func testAccRustackVmDataDiskConfig(api *fakeRustackAPI, dataDisks string) string {
+	return testAccBaseConfig(api) + fmt.Sprintf(`
+resource "rustack_port" "test" {
+  vdc_id     = rustack_vdc.test.id
+  network_id = rustack_vdc.test.default_network_id
+}
+
+resource "rustack_vm" "test" {
+  vdc_id      = rustack_vdc.test.id
+  name        = "terraform-acc"
+  cpu         = 1
+  ram         = 1
+  template_id = %[2]q
+  user_data   = "#cloud-config"
+
+  system_disk {
+    size               = 10
+    storage_profile_id = %[1]q
+  }
+
+  networks {
+    id = rustack_port.test.id
+  }
+`+dataDisks+`}
+`, api.StorageProfileID, api.TemplateID)
+}
+
+func TestFlattenVmDisks(t *testing.T) {
+	systemDisk := &rustack.Disk{ID: "system", Name: "Основной диск", Size: 10, StorageProfile: &rustack.StorageProfile{ID: "ssd"}}
+	dataDisk := &rustack.Disk{ID: "data", Size: 5}
+
+	cases := []struct {
+		name         string
+		systemDiskID string
+		disks        []*rustack.Disk
+		wantFound    bool
+		wantSystem   string
+		wantSize     int
+		wantDisks    []string
+	}{
+		{"import", "", []*rustack.Disk{systemDisk, dataDisk}, true, "system", 10, []string{"data"}},
+		{"reordered", "system", []*rustack.Disk{dataDisk, systemDisk}, true, "system", 10, []string{"data"}},
+		{"no disks", "", nil, false, "", 0, []string{}},
+		{"system disk detached", "system", []*rustack.Disk{dataDisk}, false, "system", 0, []string{"data"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			system, found, disks := flattenVmDisks(c.systemDiskID, c.disks)
+			if found != c.wantFound {
+				t.Errorf("found = %v, want %v", found, c.wantFound)
+			}
+			got := system[0].(map[string]interface{})
+			if got["id"] != c.wantSystem || got["size"] != c.wantSize {
+				t.Errorf("system disk = %v, want id %q and size %d", got, c.wantSystem, c.wantSize)
+			}
+			if !reflect.DeepEqual(disks, c.wantDisks) {
+				t.Errorf("disks = %v, want %v", disks, c.wantDisks)
+			}
+		})
+	}
+}
+
+func testAccRustackVmConfig(api *fakeRustackAPI, name string, cpu, ram int) string {
+	return testAccBaseConfig(api) + fmt.Sprintf(`
+resource "rustack_port" "test" {
+  vdc_id     = rustack_vdc.test.id
+  network_id = rustack_vdc.test.default_network_id
+}
+
+resource "rustack_disk" "test" {
+  vdc_id             = rustack_vdc.test.id
+  name               = "terraform-acc-data"
+  size               = 5
+  storage_profile_id = %[1]q
+}
+
+resource "rustack_vm" "test" {
+  vdc_id      = rustack_vdc.test.id
+  name        = %[3]q
+  cpu         = %[4]d
+  ram         = %[5]d
+  template_id = %[2]q
+  user_data   = "#cloud-config"
+
+  system_disk {
+    size               = 10
+    storage_profile_id = %[1]q
+  }
+
+  networks {
+    id = rustack_port.test.id
+  }
+
+  disks = [rustack_disk.test.id]
+  tags  = ["acc"]
+}
+`, api.StorageProfileID, api.TemplateID, name, cpu, ram)
+}
+
+func TestAccRustackVm_invalidSizing(t *testing.T) {
+	api := newFakeRustackAPI(t)
+	_, vdcID := api.CreateVdc("terraform-acc", "terraform-acc")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccRustackVmSizingConfig(api, vdcID, 1, 0.5, 2, api.StorageProfileID),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`(?s)ram: Template "Debian 10" needs at least 1 GB.*system_disk.0.size: Template "Debian 10" needs a system disk of at least 5 GB`),
+			},
+			{
+				Config:      testAccRustackVmSizingConfig(api, vdcID, 1, 1, 10, "missing"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`system_disk.0.storage_profile_id: Storage profile missing is not available in vdc "terraform-acc"`),
+			},
+			{
+				Config:             testAccRustackVmSizingConfig(api, vdcID, 2, 1.5, 10, api.StorageProfileID),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func testAccRustackVmSizingConfig(api *fakeRustackAPI, vdcID string, cpu int, ram float64, diskSize int, storageProfileID string) string {
+	return api.providerConfig() + fmt.Sprintf(`
+resource "rustack_vm" "test" {
+  vdc_id      = %[1]q
+  name        = "terraform-acc"
+  cpu         = %[2]d
+  ram         = %[3]g
+  template_id = %[4]q
+  user_data   = "#cloud-config"
+
+  system_disk {
+    size               = %[5]d
+    storage_profile_id = %[6]q
+  }
+
+  ports = ["port"]
+}
+`, vdcID, cpu, ram, api.TemplateID, diskSize, storageProfileID)
+}
@@ -0,0 +1,240 @@
+package rustack_terraform
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+var defaultRetryableStatusCodes = []int{423, 429, 500, 502, 503, 504}
+
+// RetryPolicy controls how failed API requests are repeated.
+type RetryPolicy struct {
+	MaxAttempts          int
+	BaseBackoff          time.Duration
+	MaxBackoff           time.Duration
+	Jitter               bool
+	RetryableStatusCodes []int
+}
+
+func defaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:          15,
+		BaseBackoff:          time.Second,
+		MaxBackoff:           30 * time.Second,
+		Jitter:               true,
+		RetryableStatusCodes: defaultRetryableStatusCodes,
+	}
+}
+
+func retrySchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		Description: "Retry policy for failed API requests.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"max_attempts": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					Default:      15,
+					ValidateFunc: validation.IntAtLeast(1),
+					Description:  "Number of times a request is sent before its error is returned.",
+				},
+				"base_backoff": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      "1s",
+					ValidateFunc: validateDuration,
+					Description:  "Delay before the first retry. It doubles with every next attempt.",
+				},
+				"max_backoff": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      "30s",
+					ValidateFunc: validateDuration,
+					Description:  "Upper limit of the delay between two attempts.",
+				},
+				"jitter": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     true,
+					Description: "Randomize delays so that parallel requests do not retry at the same time.",
+				},
+				"retryable_status_codes": {
+					Type:        schema.TypeSet,
+					Optional:    true,
+					Description: "HTTP status codes that are retried. Server errors are only retried for GET, HEAD and DELETE requests. Defaults to 423, 429, 500, 502, 503 and 504.",
+					Elem: &schema.Schema{
+						Type:         schema.TypeInt,
+						ValidateFunc: validation.IntBetween(400, 599),
+					},
+				},
+			},
+		},
+	}
+}
+
+func validateDuration(v interface{}, k string) (warnings []string, errors []error) {
+	value, ok := v.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", k)}
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, []error{fmt.Errorf("%s: %q is not a valid duration: %s", k, value, err)}
+	}
+	if duration < 0 {
+		return nil, []error{fmt.Errorf("%s: duration must not be negative", k)}
+	}
+	return
+}
+
+func expandRetryPolicy(d *schema.ResourceData) RetryPolicy {
+	policy := defaultRetryPolicy()
+
+	retry, ok := d.Get("retry.0").(map[string]interface{})
+	if !ok || retry == nil {
+		return policy
+	}
+
+	policy.MaxAttempts = retry["max_attempts"].(int)
+	policy.BaseBackoff, _ = time.ParseDuration(retry["base_backoff"].(string))
+	policy.MaxBackoff, _ = time.ParseDuration(retry["max_backoff"].(string))
+	policy.Jitter = retry["jitter"].(bool)
+	if codes := retry["retryable_status_codes"].(*schema.Set).List(); len(codes) > 0 {
+		policy.RetryableStatusCodes = make([]int, len(codes))
+		for i, code := range codes {
+			policy.RetryableStatusCodes[i] = code.(int)
+		}
+	}
+
+	return policy
+}
+
+func (p RetryPolicy) retryable(statusCode int) bool {
+	for _, code := range p.RetryableStatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before the next attempt after the given failed
+// one. The delay grows exponentially up to MaxBackoff; with jitter a random
+// part of up to half of it is taken away. A zero BaseBackoff retries
+// without waiting.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	if p.BaseBackoff <= 0 {
+		return 0
+	}
+	delay := p.MaxBackoff
+	if attempt < 32 {
+		if d := p.BaseBackoff << (attempt - 1); d > 0 && d < p.MaxBackoff {
+			delay = d
+		}
+	}
+	if p.Jitter && delay > 1 {
+		delay -= time.Duration(rand.Int63n(int64(delay / 2)))
+	}
+	return delay
+}
+
+// retryTransport repeats API requests answered with a retryable status code.
+// Every create, update and delete goes through it, so the policy applies to
+// all of them. Requests that may have changed something are only repeated
+// when the API refused them outright; object_locked conflicts are already
+// waited out by rcp-go itself.
+type retryTransport struct {
+	next   http.RoundTripper
+	policy RetryPolicy
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		attemptReq := req.Clone(ctx)
+		if body != nil {
+			attemptReq.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		resp, err := t.next.RoundTrip(attemptReq)
+		if attempt >= t.policy.MaxAttempts {
+			return resp, err
+		}
+		fields := map[string]interface{}{
+			"method":       req.Method,
+			"path":         req.URL.Path,
+			"attempt":      attempt,
+			"max_attempts": t.policy.MaxAttempts,
+		}
+		if err != nil {
+			if !isDialError(err) {
+				return resp, err
+			}
+			fields["error"] = err.Error()
+		} else {
+			if !t.shouldRetry(req.Method, resp.StatusCode) {
+				return resp, err
+			}
+			fields["status_code"] = resp.StatusCode
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		delay := t.policy.backoff(attempt)
+		fields["delay"] = delay.String()
+		logWarn(ctx, subsystemHTTP, "Retrying Rustack API request", fields)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// shouldRetry reports whether a response is worth another attempt. A server
+// error may come after the request took effect, so it is only retried for
+// idempotent methods; 423 and 429 mean the request was refused and are
+// retried for any method.
+func (t *retryTransport) shouldRetry(method string, statusCode int) bool {
+	if !t.policy.retryable(statusCode) {
+		return false
+	}
+	if statusCode < 500 {
+		return true
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isDialError reports whether the request failed while connecting, that is
+// before anything was sent to the API.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
@@ -0,0 +1,178 @@
+package rustack_terraform
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testRetryPolicy() RetryPolicy {
+	policy := defaultRetryPolicy()
+	policy.MaxAttempts = 4
+	policy.BaseBackoff = time.Millisecond
+	policy.MaxBackoff = 5 * time.Millisecond
+	return policy
+}
+
+// testRetryServer answers with the given responses in order and then with
+// 200 OK, recording the bodies it received.
+func testRetryServer(t *testing.T, responses ...func(http.ResponseWriter)) (*httptest.Server, *[]string) {
+	bodies := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(raw))
+		if len(bodies) <= len(responses) {
+			responses[len(bodies)-1](w)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &bodies
+}
+
+func respond(status int, body string) func(http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}
+}
+
+func doRetryRequest(t *testing.T, policy RetryPolicy, method, url string) *http.Response {
+	client := &http.Client{Transport: &retryTransport{next: http.DefaultTransport, policy: policy}}
+	req, err := http.NewRequest(method, url, strings.NewReader(`{"name":"test"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestRetryTransport_retryable(t *testing.T) {
+	server, bodies := testRetryServer(t,
+		respond(http.StatusServiceUnavailable, `{}`),
+		respond(http.StatusTooManyRequests, `{}`),
+		respond(http.StatusLocked, `{}`),
+	)
+
+	resp := doRetryRequest(t, testRetryPolicy(), http.MethodDelete, server.URL)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", resp.StatusCode)
+	}
+	if len(*bodies) != 4 {
+		t.Fatalf("expected 4 attempts, got %d", len(*bodies))
+	}
+	for i, body := range *bodies {
+		if body != `{"name":"test"}` {
+			t.Errorf("attempt %d sent body %q", i+1, body)
+		}
+	}
+}
+
+func TestRetryTransport_refusedWrite(t *testing.T) {
+	server, bodies := testRetryServer(t,
+		respond(http.StatusTooManyRequests, `{}`),
+		respond(http.StatusLocked, `{}`),
+	)
+
+	resp := doRetryRequest(t, testRetryPolicy(), http.MethodPost, server.URL)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", resp.StatusCode)
+	}
+	if len(*bodies) != 3 {
+		t.Errorf("expected 3 attempts, got %d", len(*bodies))
+	}
+}
+
+func TestRetryTransport_dialError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	attempts := 0
+	client := &http.Client{Transport: &retryTransport{
+		next: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			return http.DefaultTransport.RoundTrip(req)
+		}),
+		policy: testRetryPolicy(),
+	}}
+	if _, err := client.Post(url, "application/json", strings.NewReader(`{}`)); err == nil {
+		t.Fatal("expected an error for a closed server")
+	}
+	if attempts != 4 {
+		t.Errorf("expected 4 attempts, got %d", attempts)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestRetryTransport_notRetryable(t *testing.T) {
+	for name, c := range map[string]struct {
+		method   string
+		response func(http.ResponseWriter)
+	}{
+		"validation":       {http.MethodGet, respond(http.StatusBadRequest, `{"name":["required"]}`)},
+		"object locked":    {http.MethodPut, respond(http.StatusConflict, `{"error_alias":["object_locked"]}`)},
+		"post unavailable": {http.MethodPost, respond(http.StatusServiceUnavailable, `{}`)},
+		"put bad gateway":  {http.MethodPut, respond(http.StatusBadGateway, `{}`)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			server, bodies := testRetryServer(t, c.response)
+
+			doRetryRequest(t, testRetryPolicy(), c.method, server.URL)
+			if len(*bodies) != 1 {
+				t.Errorf("expected a single attempt, got %d", len(*bodies))
+			}
+		})
+	}
+}
+
+func TestRetryTransport_maxAttempts(t *testing.T) {
+	unavailable := respond(http.StatusBadGateway, `{}`)
+	server, bodies := testRetryServer(t, unavailable, unavailable, unavailable, unavailable, unavailable)
+
+	resp := doRetryRequest(t, testRetryPolicy(), http.MethodGet, server.URL)
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("expected the last 502 to be returned, got %d", resp.StatusCode)
+	}
+	if len(*bodies) != 4 {
+		t.Errorf("expected 4 attempts, got %d", len(*bodies))
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	policy := RetryPolicy{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second}
+	for i, delay := range expected {
+		if got := policy.backoff(i + 1); got != delay {
+			t.Errorf("attempt %d: expected %s, got %s", i+1, delay, got)
+		}
+	}
+	if got := policy.backoff(100); got != 10*time.Second {
+		t.Errorf("expected the delay to stay at max_backoff, got %s", got)
+	}
+
+	policy.Jitter = true
+	for i := 0; i < 100; i++ {
+		if got := policy.backoff(3); got <= 2*time.Second || got > 4*time.Second {
+			t.Fatalf("jittered delay %s is out of range", got)
+		}
+	}
+
+	policy.BaseBackoff = 0
+	for _, attempt := range []int{1, 5, 100} {
+		if got := policy.backoff(attempt); got != 0 {
+			t.Errorf("attempt %d: expected no delay with a zero base_backoff, got %s", attempt, got)
+		}
+	}
+}
@@ -102,6 +102,7 @@ func (args *Arguments) injectCreateRouter() {
 			Default:     false,
 			Description: "Determinate if router is system.",
 		},
-		"tags": newTagNamesResourceSchema("tags of the router"),
+		"tags":     newTagNamesResourceSchema("tags of the router"),
+		"tags_all": newTagsAllResourceSchema("tags of the router including the provider default tags"),
 	})
 }
@@ -65,7 +65,8 @@ func (args *Arguments) injectCreateS3Storage() {
 			Computed:    true,
 			Description: "secret_key for access to s3",
 		},
-		"tags": newTagNamesResourceSchema("tags of the s3"),
+		"tags":     newTagNamesResourceSchema("tags of the s3"),
+		"tags_all": newTagsAllResourceSchema("tags of the s3 including the provider default tags"),
 	})
 }
 
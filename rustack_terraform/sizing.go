@@ -0,0 +1,203 @@
+package rustack_terraform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/rustack-cloud-platform/rcp-go/rustack"
+)
+
+// sizingErrors collects the problems of a planned flavor, each reported
+// against the attribute that causes it.
+type sizingErrors []error
+
+func (e *sizingErrors) add(path, format string, args ...interface{}) {
+	*e = append(*e, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+func (e sizingErrors) err() error {
+	return errors.Join(e...)
+}
+
+// knownInt returns the planned value of an attribute unless it is only known
+// after apply.
+func knownInt(d *schema.ResourceDiff, key string) (int, bool) {
+	if !d.NewValueKnown(key) {
+		return 0, false
+	}
+	value, ok := d.Get(key).(int)
+	return value, ok
+}
+
+func knownString(d *schema.ResourceDiff, key string) (string, bool) {
+	if !d.NewValueKnown(key) {
+		return "", false
+	}
+	value, ok := d.Get(key).(string)
+	return value, ok && value != ""
+}
+
+// plannedVdc reads the vdc of a planned resource, or nil while its id is
+// only known after apply.
+func plannedVdc(d *schema.ResourceDiff, manager *rustack.Manager) (*rustack.Vdc, error) {
+	vdcID, ok := knownString(d, "vdc_id")
+	if !ok {
+		return nil, nil
+	}
+	vdc, err := manager.GetVdc(vdcID)
+	if err != nil {
+		return nil, fmt.Errorf("vdc_id: Error getting VDC: %w", err)
+	}
+	return vdc, nil
+}
+
+// checkStorageProfile checks that the storage profile is available in the
+// vdc.
+func checkStorageProfile(errs *sizingErrors, vdc *rustack.Vdc, path, storageProfileID string) error {
+	if storageProfileID == "" {
+		return nil
+	}
+	storageProfiles, err := vdc.GetStorageProfiles()
+	if err != nil {
+		return fmt.Errorf("%s: Error getting storage profiles: %w", path, err)
+	}
+	names := make([]string, 0, len(storageProfiles))
+	for _, storageProfile := range storageProfiles {
+		if storageProfile.ID == storageProfileID {
+			return nil
+		}
+		names = append(names, fmt.Sprintf("%s (%s)", storageProfile.Name, storageProfile.ID))
+	}
+	errs.add(path, "Storage profile %s is not available in vdc %q, use one of %s",
+		storageProfileID, vdc.Name, strings.Join(names, ", "))
+	return nil
+}
+
+// customizeDiffVmSizing checks cpu, ram and the disks of a VM at plan time,
+// so that an invalid flavor does not fail halfway through the create.
+func customizeDiffVmSizing(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	c, ok := meta.(*CombinedConfig)
+	if !ok || (d.Id() != "" && !d.HasChanges("cpu", "ram", "system_disk", "data_disk", "template_id", "vdc_id")) {
+		return nil
+	}
+	manager := c.rustackManager().WithContext(ctx)
+
+	var errs sizingErrors
+	cpu, _ := knownInt(d, "cpu")
+	diskSize, _ := knownInt(d, "system_disk.0.size")
+	ram := 0.0
+	if d.NewValueKnown("ram") {
+		if ram = d.Get("ram").(float64); ram <= 0 {
+			errs.add("ram", "Expected a positive amount of memory, got %g", ram)
+		}
+	}
+	if d.NewValueKnown("system_disk.0.size") && diskSize < 1 {
+		errs.add("system_disk.0.size", "Expected a size of at least 1 GB, got %d", diskSize)
+	}
+
+	if templateID, ok := knownString(d, "template_id"); ok {
+		template, err := manager.GetTemplate(templateID)
+		if err != nil {
+			return fmt.Errorf("template_id: Error getting template: %w", err)
+		}
+		if cpu > 0 && cpu < template.MinCpu {
+			errs.add("cpu", "Template %q needs at least %d cpus, got %d", template.Name, template.MinCpu, cpu)
+		}
+		if ram > 0 && ram < template.MinRam {
+			errs.add("ram", "Template %q needs at least %g GB of memory, got %g", template.Name, template.MinRam, ram)
+		}
+		if diskSize > 0 && diskSize < template.MinHdd {
+			errs.add("system_disk.0.size", "Template %q needs a system disk of at least %d GB, got %d", template.Name, template.MinHdd, diskSize)
+		}
+	}
+
+	vdc, err := plannedVdc(d, manager)
+	if err != nil {
+		return err
+	}
+	if vdc != nil {
+		storageProfileID, _ := knownString(d, "system_disk.0.storage_profile_id")
+		if err := checkStorageProfile(&errs, vdc, "system_disk.0.storage_profile_id", storageProfileID); err != nil {
+			return err
+		}
+
+		for i := range d.Get("data_disk").([]interface{}) {
+			path := fmt.Sprintf("data_disk.%d.storage_profile_id", i)
+			dataDiskStorageProfileID, _ := knownString(d, path)
+			if err := checkStorageProfile(&errs, vdc, path, dataDiskStorageProfileID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return errs.err()
+}
+
+// customizeDiffKubernetesSizing is customizeDiffVmSizing for the nodes of a
+// kubernetes cluster.
+func customizeDiffKubernetesSizing(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	c, ok := meta.(*CombinedConfig)
+	if !ok || (d.Id() != "" && !d.HasChanges("node_cpu", "node_ram", "node_disk_size", "node_storage_profile_id", "template_id", "platform", "vdc_id")) {
+		return nil
+	}
+	manager := c.rustackManager().WithContext(ctx)
+
+	var errs sizingErrors
+	cpu, _ := knownInt(d, "node_cpu")
+	ram, _ := knownInt(d, "node_ram")
+	diskSize, _ := knownInt(d, "node_disk_size")
+	if d.NewValueKnown("node_ram") && ram < 1 {
+		errs.add("node_ram", "Expected at least 1 GB of memory, got %d", ram)
+	}
+	if d.NewValueKnown("node_disk_size") && diskSize < 1 {
+		errs.add("node_disk_size", "Expected a size of at least 1 GB, got %d", diskSize)
+	}
+
+	if templateID, ok := knownString(d, "template_id"); ok {
+		template, err := manager.GetKubernetesTemplate(templateID)
+		if err != nil {
+			return fmt.Errorf("template_id: Error getting template: %w", err)
+		}
+		if cpu > 0 && cpu < template.MinNodeCpu {
+			errs.add("node_cpu", "Template %q needs at least %d cpus per node, got %d", template.Name, template.MinNodeCpu, cpu)
+		}
+		if ram > 0 && ram < template.MinNodeRam {
+			errs.add("node_ram", "Template %q needs at least %d GB of memory per node, got %d", template.Name, template.MinNodeRam, ram)
+		}
+		if diskSize > 0 && diskSize < template.MinNodeHdd {
+			errs.add("node_disk_size", "Template %q needs a node disk of at least %d GB, got %d", template.Name, template.MinNodeHdd, diskSize)
+		}
+	}
+
+	vdc, err := plannedVdc(d, manager)
+	if err != nil {
+		return err
+	}
+	if vdc == nil {
+		return errs.err()
+	}
+
+	platformID, platformKnown := knownString(d, "platform")
+	if d.NewValueKnown("platform") && !platformKnown && strings.EqualFold(vdc.Hypervisor.Type, "vmware") {
+		errs.add("platform", "Required for %s hypervisors", vdc.Hypervisor.Type)
+	}
+	if platformKnown {
+		platform, err := manager.GetPlatform(platformID)
+		if err != nil {
+			return fmt.Errorf("platform: Error getting platform: %w", err)
+		}
+		if platform.Hypervisor != nil && platform.Hypervisor.ID != "" && platform.Hypervisor.ID != vdc.Hypervisor.ID {
+			errs.add("platform", "Platform %q belongs to another hypervisor than vdc %q", platform.Name, vdc.Name)
+		}
+	}
+
+	storageProfileID, _ := knownString(d, "node_storage_profile_id")
+	if err := checkStorageProfile(&errs, vdc, "node_storage_profile_id", storageProfileID); err != nil {
+		return err
+	}
+
+	return errs.err()
+}
@@ -1,6 +1,8 @@
 package rustack_terraform
 
 import (
+	"context"
+
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/rustack-cloud-platform/rcp-go/rustack"
 )
@@ -63,3 +65,91 @@ func newTagsDatasourceSchema(description string) *schema.Schema {
 		Description: description,
 	}
 }
+
+// newTagsAllResourceSchema describes the tags a resource has in the API:
+// its own tags together with the provider default tags.
+func newTagsAllResourceSchema(description string) *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeSet,
+		Computed: true,
+		Elem: &schema.Schema{
+			Type:        schema.TypeString,
+			Description: "name of the Tag",
+		},
+		Description: description,
+	}
+}
+
+func expandDefaultTags(d *schema.ResourceData) []string {
+	var tags []string
+	if v, ok := d.GetOk("default_tags.0.tags"); ok {
+		for _, tag := range v.(*schema.Set).List() {
+			tags = append(tags, tag.(string))
+		}
+	}
+	return tags
+}
+
+func defaultTagNames(meta interface{}) *schema.Set {
+	tags := schema.NewSet(schema.HashString, nil)
+	if config, ok := meta.(*CombinedConfig); ok {
+		for _, tag := range config.defaultTags {
+			tags.Add(tag)
+		}
+	}
+	return tags
+}
+
+// mergeTagNames returns the tags of a resource together with the provider
+// default tags. Sets from the schema hash their elements differently, so the
+// result is built with schema.HashString like every set it is compared to.
+func mergeTagNames(meta interface{}, tags *schema.Set) *schema.Set {
+	all := defaultTagNames(meta)
+	for _, tag := range tags.List() {
+		all.Add(tag)
+	}
+	return all
+}
+
+// expandTags returns the tags to send to the API.
+func expandTags(d *schema.ResourceData, meta interface{}) []rustack.Tag {
+	return unmarshalTagNames(mergeTagNames(meta, d.Get("tags").(*schema.Set)))
+}
+
+// setTags stores the tags read from the API. tags_all gets all of them and
+// tags only those that are not provider default tags, so default tags do not
+// show up as a diff of the resource. A tag that is also configured on the
+// resource itself stays in tags.
+func setTags(d *schema.ResourceData, meta interface{}, tags []rustack.Tag) error {
+	defaults := defaultTagNames(meta)
+	configured := d.Get("tags").(*schema.Set)
+
+	own := make([]interface{}, 0, len(tags))
+	for _, tag := range tags {
+		if defaults.Contains(tag.Name) && !configured.Contains(tag.Name) {
+			continue
+		}
+		own = append(own, tag.Name)
+	}
+
+	if err := d.Set("tags", own); err != nil {
+		return err
+	}
+	return d.Set("tags_all", marshalTagNames(tags))
+}
+
+// customizeDiffTags plans tags_all from the configured tags and the provider
+// default tags. Resources update their tags when tags_all changes, which
+// also happens when only the default tags were changed.
+func customizeDiffTags(_ context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown("tags") {
+		return d.SetNewComputed("tags_all")
+	}
+
+	all := mergeTagNames(meta, d.Get("tags").(*schema.Set))
+	current := schema.NewSet(schema.HashString, d.Get("tags_all").(*schema.Set).List())
+	if current.Equal(all) {
+		return nil
+	}
+	return d.SetNew("tags_all", all.List())
+}
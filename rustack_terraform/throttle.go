@@ -0,0 +1,134 @@
+package rustack_terraform
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitTransport spaces out API requests so that a large apply stays
+// below the request rate the API accepts. Every attempt of a retried request
+// counts against the limit.
+type rateLimitTransport struct {
+	next    http.RoundTripper
+	limiter *rate.Limiter
+}
+
+func newRateLimitTransport(next http.RoundTripper, requestsPerSecond float64) http.RoundTripper {
+	if requestsPerSecond <= 0 {
+		return next
+	}
+	return &rateLimitTransport{
+		next:    next,
+		limiter: rate.NewLimiter(rate.Limit(requestsPerSecond), 1),
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	start := time.Now()
+	if err := t.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	if waited := time.Since(start); waited > time.Millisecond {
+		logTrace(ctx, subsystemHTTP, "Rustack API request delayed by the rate limit", map[string]interface{}{
+			"method": req.Method,
+			"path":   req.URL.Path,
+			"delay":  waited.String(),
+		})
+	}
+	return t.next.RoundTrip(req)
+}
+
+// mutationQueue limits how many creates, updates and deletes run at the same
+// time in one VDC. The API locks a VDC while a task is running in it, so
+// concurrent mutations are queued in the provider instead of failing with
+// object_locked and being retried.
+type mutationQueue struct {
+	limit int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func newMutationQueue(limit int) *mutationQueue {
+	return &mutationQueue{limit: limit, slots: map[string]chan struct{}{}}
+}
+
+// acquire blocks until the mutation may run in the given scope. The returned
+// function releases the slot. An empty scope or a queue without limit is not
+// waited for.
+func (q *mutationQueue) acquire(ctx context.Context, scope string) (func(), error) {
+	if q == nil || q.limit <= 0 || scope == "" {
+		return func() {}, nil
+	}
+
+	q.mu.Lock()
+	slots, ok := q.slots[scope]
+	if !ok {
+		slots = make(chan struct{}, q.limit)
+		q.slots[scope] = slots
+	}
+	q.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+	default:
+		start := time.Now()
+		logDebug(ctx, subsystemHTTP, "Waiting for other changes in the same scope", map[string]interface{}{
+			"scope": scope,
+			"limit": q.limit,
+		})
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		logDebug(ctx, subsystemHTTP, "Continuing after other changes in the same scope", map[string]interface{}{
+			"scope": scope,
+			"delay": time.Since(start).String(),
+		})
+	}
+	return func() { <-slots }, nil
+}
+
+// mutationFunc is the signature shared by the create, update and delete
+// functions of a resource.
+type mutationFunc = func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics
+
+// serializeInVdc queues a create, update or delete function of a resource
+// that lives in a VDC behind the other mutations of that VDC.
+func serializeInVdc(f mutationFunc) mutationFunc {
+	return serializeIn("vdc_id", "vdc", f)
+}
+
+// serializeInProject is serializeInVdc for resources that live in a project.
+func serializeInProject(f mutationFunc) mutationFunc {
+	return serializeIn("project_id", "project", f)
+}
+
+func serializeIn(key, kind string, f mutationFunc) mutationFunc {
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		var queue *mutationQueue
+		if c, ok := meta.(*CombinedConfig); ok {
+			queue = c.mutations
+		}
+
+		scope := ""
+		if id := d.Get(key).(string); id != "" {
+			scope = kind + "/" + id
+		}
+		release, err := queue.acquire(ctx, scope)
+		if err != nil {
+			return diag.Errorf("%s: Error waiting for other changes in %s %s: %s", key, kind, d.Get(key), err)
+		}
+		defer release()
+
+		return f(ctx, d, meta)
+	}
+}
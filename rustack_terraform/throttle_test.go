@@ -0,0 +1,105 @@
+package rustack_terraform
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestRateLimitTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(okHandler))
+	defer server.Close()
+
+	client := &http.Client{Transport: newRateLimitTransport(http.DefaultTransport, 20)}
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		resp.Body.Close()
+	}
+	// the first request is sent right away, the next four 50ms apart
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("expected 5 requests at 20/s to take at least 200ms, took %s", elapsed)
+	}
+}
+
+func TestRateLimitTransport_disabled(t *testing.T) {
+	if _, ok := newRateLimitTransport(http.DefaultTransport, 0).(*rateLimitTransport); ok {
+		t.Error("expected no rate limit for 0 requests per second")
+	}
+}
+
+func TestMutationQueue_cancel(t *testing.T) {
+	queue := newMutationQueue(1)
+	release, err := queue.acquire(context.Background(), "vdc/1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// another scope is not blocked
+	other, err := queue.acquire(context.Background(), "vdc/2")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	other()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := queue.acquire(ctx, "vdc/1"); err != context.DeadlineExceeded {
+		t.Errorf("expected the wait to end with the context, got %v", err)
+	}
+
+	release()
+	release, err = queue.acquire(context.Background(), "vdc/1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	release()
+}
+
+func TestSerializeInVdc(t *testing.T) {
+	cases := map[int]int32{0: 6, 1: 1, 2: 2}
+	for limit, expected := range cases {
+		var running, peak int32
+		create := serializeInVdc(func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(50 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+
+		meta := &CombinedConfig{mutations: newMutationQueue(limit)}
+		resourceSchema := map[string]*schema.Schema{"vdc_id": {Type: schema.TypeString, Optional: true}}
+		var wg sync.WaitGroup
+		for i := 0; i < 6; i++ {
+			d := schema.TestResourceDataRaw(t, resourceSchema, map[string]interface{}{"vdc_id": "vdc-1"})
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if diags := create(context.Background(), d, meta); diags.HasError() {
+					t.Errorf("unexpected error: %v", diags)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if peak != expected {
+			t.Errorf("limit %d: expected a peak of %d concurrent changes, got %d", limit, expected, peak)
+		}
+	}
+}
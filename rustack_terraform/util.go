@@ -3,7 +3,6 @@ package rustack_terraform
 import (
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/pkg/errors"
@@ -469,19 +468,6 @@ func getRouterByNetwork(manager rustack.Manager, network rustack.Network) (route
 	return
 }
 
-func repeatOnError(f func() error, targerInterface interface{ WaitLock() error }) (err error) {
-	for j := 0; j < 15; j++ {
-		targerInterface.WaitLock()
-		err = f()
-		if err == nil {
-			return
-		}
-		fmt.Printf("err: %v\n", err)
-		time.Sleep(time.Second)
-	}
-	return
-}
-
 func GetServiseNetworkByVdc(vdc *rustack.Vdc) (*rustack.Network, error) {
 	allNetworks, err := vdc.GetNetworks()
 	if err != nil {
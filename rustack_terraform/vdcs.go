@@ -9,9 +9,10 @@ func (args *Arguments) injectCreateVdc() {
 	args.merge(Arguments{
 		"project_id": {
 			Type:        schema.TypeString,
-			Required:    true,
+			Optional:    true,
+			Computed:    true,
 			ForceNew:    true,
-			Description: "id of the Project",
+			Description: "id of the Project. Defaults to the provider default project",
 		},
 		"name": {
 			Type:     schema.TypeString,
@@ -83,7 +84,8 @@ func (args *Arguments) injectCreateVdc() {
 				},
 			},
 		},
-		"tags": newTagNamesResourceSchema("tags of the VDC"),
+		"tags":     newTagNamesResourceSchema("tags of the VDC"),
+		"tags_all": newTagsAllResourceSchema("tags of the VDC including the provider default tags"),
 	})
 }
 
@@ -98,6 +100,20 @@ func (args *Arguments) injectContextVdcById() {
 	})
 }
 
+// injectContextDefaultVdcById is used by resources, which fall back to the
+// provider default vdc when vdc_id is omitted.
+func (args *Arguments) injectContextDefaultVdcById() {
+	args.merge(Arguments{
+		"vdc_id": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			ForceNew:    true,
+			Description: "id of the VDC. Defaults to the provider default vdc",
+		},
+	})
+}
+
 func (args *Arguments) injectContextVdcByIdForData() {
 	args.merge(Arguments{
 		"vdc_id": {
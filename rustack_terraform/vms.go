@@ -1,10 +1,18 @@
 package rustack_terraform
 
 import (
+	"strings"
+
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
+// normalizeUserData drops the differences between two copies of a cloud-init
+// script that do not change what cloud-init does with it.
+func normalizeUserData(userData string) string {
+	return strings.TrimSpace(strings.ReplaceAll(userData, "\r\n", "\n"))
+}
+
 func (args *Arguments) injectContextGetVm() {
 	args.merge(Arguments{
 		"name": {
@@ -35,6 +43,8 @@ func (args *Arguments) injectContextVmById() {
 func (args *Arguments) injectCreateVm() {
 	systemDisk := Defaults()
 	systemDisk.injectSystemDisk()
+	dataDisk := Defaults()
+	dataDisk.injectDataDisk()
 
 	args.merge(Arguments{
 		"name": {
@@ -64,9 +74,13 @@ func (args *Arguments) injectCreateVm() {
 			Description: "id of the Template",
 		},
 		"user_data": {
-			Type:        schema.TypeString,
-			Required:    true,
-			ForceNew:    true,
+			Type:     schema.TypeString,
+			Required: true,
+			ForceNew: true,
+			// the API may return the script with other line endings
+			DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+				return normalizeUserData(old) == normalizeUserData(new)
+			},
 			Description: "script for cloud-init",
 		},
 		"system_disk": {
@@ -79,12 +93,26 @@ func (args *Arguments) injectCreateVm() {
 			},
 			Description: "System disk.",
 		},
+		"data_disk": {
+			Type:     schema.TypeList,
+			Optional: true,
+			Elem: &schema.Resource{
+				Schema: dataDisk,
+			},
+			Description: "Disks created and deleted together with the Vm.",
+		},
 		"disks": {
 			Type:        schema.TypeSet,
 			Optional:    true,
-			Description: "list of Disks attached to the Vm",
+			Description: "list of Disks attached to the Vm. Disks attached with rustack_disk_attachment are not listed",
 			Elem:        &schema.Schema{Type: schema.TypeString},
 		},
+		"force_detach": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "detach Disks listed in disks from another Vm they are attached to instead of failing",
+		},
 		"ports": {
 			Type:        schema.TypeList,
 			Optional:    true,
@@ -96,13 +124,13 @@ func (args *Arguments) injectCreateVm() {
 			Elem:        &schema.Schema{Type: schema.TypeString},
 		},
 		"networks": {
-			Type:         schema.TypeList,
-			Optional:     true,
-			Computed:     true,
-			ExactlyOneOf: []string{"ports", "networks"},
-			MinItems:     1,
-			MaxItems:     10,
-			Description:  "List of Ports connected to the Vm",
+			Type:          schema.TypeList,
+			Optional:      true,
+			Computed:      true,
+			ConflictsWith: []string{"ports"},
+			MinItems:      1,
+			MaxItems:      10,
+			Description:   "List of Ports connected to the Vm. Ports connected with rustack_port_attachment are not listed",
 			Elem: &schema.Resource{
 				Schema: map[string]*schema.Schema{
 					"id": {
@@ -129,7 +157,14 @@ func (args *Arguments) injectCreateVm() {
 			Computed:    true,
 			Description: "floating ip for the Vm. May be omitted",
 		},
-		"tags": newTagNamesResourceSchema("tags of the Vm"),
+		"hotadd_feature": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Computed:    true,
+			Description: "allow adding cpus and memory without powering the Vm off",
+		},
+		"tags":     newTagNamesResourceSchema("tags of the Vm"),
+		"tags_all": newTagsAllResourceSchema("tags of the Vm including the provider default tags"),
 		"power": {
 			Type:        schema.TypeBool,
 			Optional:    true,
@@ -250,3 +285,36 @@ func (args *Arguments) injectSystemDisk() {
 		},
 	})
 }
+
+func (args *Arguments) injectDataDisk() {
+	args.merge(Arguments{
+		"id": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "id of the Disk",
+		},
+		"name": {
+			Type:         schema.TypeString,
+			Required:     true,
+			ValidateFunc: validation.StringLenBetween(1, 100),
+			Description:  "name of the Disk, unique among the data_disk blocks",
+		},
+		"size": {
+			Type:         schema.TypeInt,
+			Required:     true,
+			ValidateFunc: validation.IntAtLeast(1),
+			Description:  "the size of the Disk in gigabytes",
+		},
+		"storage_profile_id": {
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: "id of the Storage profile",
+		},
+		"delete_on_termination": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     true,
+			Description: "delete the Disk when it is removed from the Vm or the Vm is deleted, otherwise it is only detached",
+		},
+	})
+}
@@ -0,0 +1,146 @@
+package rustack_terraform
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rustack-cloud-platform/rcp-go/rustack"
+)
+
+var (
+	// lockPollInterval is the delay between two lock state requests.
+	lockPollInterval = time.Second
+	// lockReportInterval is how often a still running wait is logged.
+	lockReportInterval = 30 * time.Second
+)
+
+// lockable is implemented by every API object that is locked while a task
+// is running on it.
+type lockable interface {
+	WaitLock() error
+}
+
+// lockTarget names a lockable object and the API path its lock state is
+// read from.
+type lockTarget struct {
+	kind string
+	id   string
+	name string
+	path string
+}
+
+func (t lockTarget) String() string {
+	if t.name == "" {
+		return fmt.Sprintf("%s %s", t.kind, t.id)
+	}
+	return fmt.Sprintf("%s %q (%s)", t.kind, t.name, t.id)
+}
+
+func getLockTarget(object lockable) (target lockTarget, ok bool) {
+	switch o := object.(type) {
+	case *rustack.Project:
+		return lockTarget{"project", o.ID, o.Name, "v1/project/" + o.ID}, true
+	case *rustack.Vdc:
+		return lockTarget{"vdc", o.ID, o.Name, "v1/vdc/" + o.ID}, true
+	case *rustack.Vm:
+		return lockTarget{"vm", o.ID, o.Name, "v1/vm/" + o.ID}, true
+	case *rustack.Disk:
+		return lockTarget{"disk", o.ID, o.Name, "v1/disk/" + o.ID}, true
+	case *rustack.Network:
+		return lockTarget{"network", o.ID, o.Name, "v1/network/" + o.ID}, true
+	case *rustack.Port:
+		return lockTarget{"port", o.ID, "", "v1/port/" + o.ID}, true
+	case *rustack.Router:
+		return lockTarget{"router", o.ID, o.Name, "v1/router/" + o.ID}, true
+	case *rustack.FirewallTemplate:
+		return lockTarget{"firewall template", o.ID, o.Name, "v1/firewall/" + o.ID}, true
+	case *rustack.FirewallRule:
+		return lockTarget{"firewall rule", o.ID, o.Name, fmt.Sprintf("v1/firewall/%s/rule/%s", o.TemplateId, o.ID)}, true
+	case *rustack.LoadBalancer:
+		return lockTarget{"load balancer", o.ID, o.Name, "v1/lbaas/" + o.ID}, true
+	case *rustack.Kubernetes:
+		return lockTarget{"kubernetes", o.ID, o.Name, "v1/kubernetes/" + o.ID}, true
+	case *rustack.S3Storage:
+		return lockTarget{"s3 storage", o.ID, o.Name, "v1/s3_storage/" + o.ID}, true
+	}
+	return lockTarget{}, false
+}
+
+// waitLock polls the lock state of the object until the API reports it as
+// unlocked. The wait is bounded by ctx, so the resource timeout configured
+// for the current operation applies to it.
+func waitLock(ctx context.Context, manager *rustack.Manager, object lockable) error {
+	target, ok := getLockTarget(object)
+	if !ok {
+		return object.WaitLock()
+	}
+
+	// The lock state changes while we wait, so it is never read from the cache
+	manager = manager.WithContext(withoutLookupCache(ctx))
+	start := time.Now()
+	reported := start
+	for {
+		var state struct {
+			Locked bool `json:"locked"`
+		}
+		if err := manager.Get(target.path, rustack.Defaults(), &state); err != nil {
+			if ctx.Err() != nil {
+				return lockTimeoutError(ctx, target, time.Since(start))
+			}
+			// An object that is gone, e.g. after a delete, has no lock to wait for
+			if isNotFound(err) {
+				return nil
+			}
+			if !isTransient(err) {
+				return errors.Wrapf(err, "Error waiting for %s to be unlocked", target)
+			}
+			logWarn(ctx, subsystemWait, "Error reading the lock state, trying again", map[string]interface{}{
+				"object": target.String(),
+				"error":  err.Error(),
+			})
+		} else if !state.Locked {
+			if reported != start {
+				logInfo(ctx, subsystemWait, "Object unlocked", map[string]interface{}{
+					"object":  target.String(),
+					"elapsed": time.Since(start).Round(time.Second).String(),
+				})
+			}
+			return nil
+		}
+
+		if time.Since(reported) >= lockReportInterval {
+			reported = time.Now()
+			logInfo(ctx, subsystemWait, "Still waiting for the object to be unlocked", map[string]interface{}{
+				"object":  target.String(),
+				"elapsed": reported.Sub(start).Round(time.Second).String(),
+			})
+		}
+
+		select {
+		case <-ctx.Done():
+			return lockTimeoutError(ctx, target, time.Since(start))
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+func lockTimeoutError(ctx context.Context, target lockTarget, elapsed time.Duration) error {
+	if ctx.Err() == context.Canceled {
+		return fmt.Errorf("Cancelled while waiting for %s to be unlocked", target)
+	}
+	return fmt.Errorf("Timeout while waiting for %s to be unlocked: it stayed locked for %s. "+
+		"A task may still be running on it; increase the resource timeouts if it is expected to take longer",
+		target, elapsed.Round(time.Second))
+}
+
+// runUnlocked waits for the object to be unlocked and calls f. Failed
+// requests made by f are retried by the API client according to the
+// provider retry policy.
+func runUnlocked(ctx context.Context, manager *rustack.Manager, f func() error, object lockable) error {
+	if err := waitLock(ctx, manager, object); err != nil {
+		return err
+	}
+	return f()
+}
@@ -0,0 +1,83 @@
+package rustack_terraform
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rustack-cloud-platform/rcp-go/rustack"
+)
+
+func setLockPollInterval(t *testing.T, interval time.Duration) {
+	previous := lockPollInterval
+	lockPollInterval = interval
+	t.Cleanup(func() { lockPollInterval = previous })
+}
+
+func TestWaitLock_unlocked(t *testing.T) {
+	setLockPollInterval(t, 10*time.Millisecond)
+	api := newFakeRustackAPI(t)
+	id := api.put("project", fakeObject{"name": "waiting"})
+	api.Lock(id, 3)
+
+	project := &rustack.Project{ID: id, Name: "waiting"}
+	if err := waitLock(context.Background(), api.manager(), project); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestWaitLock_deleted(t *testing.T) {
+	api := newFakeRustackAPI(t)
+
+	vm := &rustack.Vm{ID: api.newID(), Name: "gone"}
+	if err := waitLock(context.Background(), api.manager(), vm); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestWaitLock_timeout(t *testing.T) {
+	setLockPollInterval(t, 10*time.Millisecond)
+	api := newFakeRustackAPI(t)
+	id := api.put("project", fakeObject{"name": "stuck"})
+	api.Lock(id, 1<<30)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	project := &rustack.Project{ID: id, Name: "stuck"}
+	err := waitLock(ctx, api.manager(), project)
+	if err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	for _, expected := range []string{"Timeout", `project "stuck" (` + id + ")", "stayed locked"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("error %q does not mention %q", err, expected)
+		}
+	}
+}
+
+func TestRunUnlocked(t *testing.T) {
+	setLockPollInterval(t, 10*time.Millisecond)
+	api := newFakeRustackAPI(t)
+	id := api.put("project", fakeObject{"name": "failing"})
+	api.Lock(id, 2)
+
+	calls := 0
+	failing := func() error {
+		calls++
+		if api.locks[id] > 0 {
+			return errors.New("called while locked")
+		}
+		return errors.New("still failing")
+	}
+
+	err := runUnlocked(context.Background(), api.manager(), failing, &rustack.Project{ID: id})
+	if err == nil || err.Error() != "still failing" {
+		t.Fatalf("expected the error of f to be returned, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected f to be called once, got %d", calls)
+	}
+}